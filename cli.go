@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command はMCPクライアントなしで日報を扱うサブコマンド
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, env *commandEnv, args []string) error
+}
+
+// commands は指定できるサブコマンド
+var commands = []command{
+	{name: "post", summary: "日報に投稿する（例: post --yes \"テキスト\"、テキストを省略すると標準入力から読む）", run: runPostCommand},
+	{name: "today", summary: "今日（--dateで指定した日）の日報のエントリを表示する", run: runTodayCommand},
+	{name: "search", summary: "esa.ioの投稿を検索する（例: search --tag go キーワード）", run: runSearchCommand},
+}
+
+// commandEnv はサブコマンドの実行環境
+type commandEnv struct {
+	config EsaConfig
+	// newClient はResolveTeamで解決したプロファイルのesa.ioクライアントを生成する
+	newClient func(team string) (EsaClientInterface, error)
+	stdin     io.Reader
+	stdout    io.Writer
+	// stderr は結果の出力（--jsonを含む）とは別に伝える保留した投稿のやり直しの結果の出力先
+	stderr io.Writer
+	clock  Clock
+	// outbox は前回までの実行で保留した投稿（nilなら保留しない）
+	outbox *Outbox
+}
+
+// newCommandEnv は設定を反映したサブコマンドの実行環境を返す
+// MCPサーバーと同じデバウンス・プレフィックスの設定で投稿する
+func newCommandEnv(config EsaConfig) *commandEnv {
+	factory := NewDefaultHandlerFactory(config)
+	// プロセス内のみのアウトボックスは終了すると失われるため、保留せずにエラーを返す
+	if config.Outbox.Store == outboxMemoryName {
+		SetOutbox(nil)
+	}
+	return &commandEnv{
+		config:    config,
+		newClient: factory.CreateEsaClient,
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		clock:     defaultClock,
+		outbox:    currentOutbox(),
+	}
+}
+
+// flushOutbox は前回までの実行で保留した投稿をやり直し、結果を標準エラー出力に表示する
+// CLIには保留した投稿をやり直し続けるプロセスがないため、postを実行するたびに行う
+// やり直せなかった投稿は失敗した理由を記録して保留したままにし、次の実行でまたやり直す
+func (env *commandEnv) flushOutbox(ctx context.Context) {
+	if env.outbox == nil {
+		return
+	}
+	posted, err := env.outbox.Flush(ctx, env.newClient)
+	if err != nil {
+		fmt.Fprintf(env.stderr, "保留した投稿をやり直せませんでした: %v\n", err)
+		return
+	}
+	for _, item := range posted {
+		fmt.Fprintf(env.stderr, "保留していた投稿（%s）を%sの日報に書きました\n", item.ID, item.CreatedAt.Format("2006-01-02 15:04"))
+	}
+
+	items, err := env.outbox.List()
+	if err != nil {
+		fmt.Fprintf(env.stderr, "保留した投稿の一覧を取得できませんでした: %v\n", err)
+		return
+	}
+	for _, item := range items {
+		fmt.Fprintf(env.stderr, "保留している投稿（%s）をやり直せませんでした: %s\n", item.ID, item.LastError)
+	}
+}
+
+// client はteamで指定したチーム（空ならデフォルトのチーム）のプロファイル名とesa.ioクライアントを返す
+func (env *commandEnv) client(team string) (string, EsaClientInterface, error) {
+	team, err := env.config.ResolveTeam(team)
+	if err != nil {
+		return "", nil, err
+	}
+	esaClient, err := env.newClient(team)
+	if err != nil {
+		return "", nil, err
+	}
+	return team, esaClient, nil
+}
+
+// print は--jsonが指定されていればresultをJSONで、そうでなければtextを出力する
+func (env *commandEnv) print(asJSON bool, result any, text string) error {
+	if asJSON {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	_, err := fmt.Fprintln(env.stdout, text)
+	return err
+}
+
+// runCommand はargsの最初の要素で指定したサブコマンドを実行する
+func runCommand(ctx context.Context, env *commandEnv, args []string) error {
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(ctx, env, args[1:])
+		}
+	}
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return fmt.Errorf("サブコマンド%sはありません（%s）", args[0], strings.Join(names, "・"))
+}
+
+// newCommandFlagSet はサブコマンドのオプションを解析するFlagSetを返す
+func newCommandFlagSet(name, usage string, env *commandEnv) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stdout)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "使い方: times_esa_mcp_server [オプション] %s\n\nオプション:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseInterspersed はオプションと位置引数が混ざった引数を解析し、位置引数を返す
+// 「post "テキスト" --yes」のようにオプションを後ろに書けるようにする（「--」以降はすべて位置引数）
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// stringsFlag は複数回指定できる文字列のオプション
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// runPostCommand は日報に投稿するサブコマンド
+// MCPのツールと同じsubmitDailyReportWithClockで投稿し、--yesをconfirmed_by_userの代わりとする
+func runPostCommand(ctx context.Context, env *commandEnv, args []string) error {
+	fs := newCommandFlagSet("post", "post [--yes] [--team チーム] [--date YYYY-MM-DD] [--time HH:MM] [--section 見出し] [テキスト...]", env)
+	yes := fs.Bool("yes", false, "内容を確認済みとして投稿する（必須）")
+	team := fs.String("team", "", "投稿先のチーム（省略時はデフォルトのチーム）")
+	date := fs.String("date", "", "過去の日報に書く場合の日付（YYYY-MM-DD形式、--timeも指定する）")
+	clock := fs.String("time", "", "エントリの時刻（HH:MM形式、日報の時刻順の位置に挿入する）")
+	section := fs.String("section", "", "エントリを書く日報の見出し（例: やったこと）")
+	asJSON := fs.Bool("json", false, "結果をJSONで出力する")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		return newToolError(ErrNotConfirmed, "投稿するには内容を確認して--yesを指定してください")
+	}
+
+	// テキストを省略するか「-」を指定した場合は標準入力から読む（gitのフックやパイプから投稿するため）
+	text := strings.Join(positional, " ")
+	if len(positional) == 0 || text == "-" {
+		data, err := io.ReadAll(env.stdin)
+		if err != nil {
+			return fmt.Errorf("標準入力の読み込みに失敗: %w", err)
+		}
+		text = strings.TrimRight(string(data), "\r\n")
+	}
+
+	resolved, esaClient, err := env.client(*team)
+	if err != nil {
+		return err
+	}
+
+	// 前回までに保留した投稿を先に書き、日報の順序を保つ
+	env.flushOutbox(ctx)
+
+	params := &TimesEsaPostRequest{Text: text, ConfirmedByUser: *yes, Team: resolved, Date: *date, Time: *clock, Section: *section}
+	result, err := submitDailyReportWithClock(ctx, nil, params, esaClient, env.clock.Now())
+	if err != nil {
+		return err
+	}
+	text = result.Message
+	if result.Queued == nil {
+		text += fmt.Sprintf("（#%d %s/%s）", result.Post.Number, result.Post.Category, result.Post.Name)
+	}
+	return env.print(*asJSON, result, text)
+}
+
+// runTodayCommand は日報のエントリを表示するサブコマンド
+func runTodayCommand(ctx context.Context, env *commandEnv, args []string) error {
+	fs := newCommandFlagSet("today", "today [--date YYYY-MM-DD] [--team チーム]", env)
+	date := fs.String("date", "", "表示する日報の日付（YYYY-MM-DD形式、省略時は今日）")
+	team := fs.String("team", "", "読み取り対象のチーム（省略時はデフォルトのチーム）")
+	asJSON := fs.Bool("json", false, "結果をJSONで出力する")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return newToolError(ErrInvalidParams, "余分な引数があります: %s", strings.Join(positional, " "))
+	}
+
+	_, esaClient, err := env.client(*team)
+	if err != nil {
+		return err
+	}
+
+	result, err := readDailyReportWithClock(ctx, &TimesEsaReadRequest{Date: *date}, esaClient, env.clock.Now())
+	if err != nil {
+		return err
+	}
+	return env.print(*asJSON, result, formatReadResponse(result))
+}
+
+// runSearchCommand はesa.ioの投稿を検索するサブコマンド（位置引数はキーワードとして扱う）
+func runSearchCommand(ctx context.Context, env *commandEnv, args []string) error {
+	fs := newCommandFlagSet("search", "search [--tag タグ] [--user ユーザー] [キーワード...]", env)
+	var params EsaSearchRequest
+	var keywords, tags stringsFlag
+	fs.Var(&keywords, "keyword", "本文・タイトルに含まれるキーワード（複数回指定できる）")
+	fs.Var(&tags, "tag", "タグ（複数回指定できる）")
+	fs.StringVar(&params.User, "user", "", "投稿者のscreen_name")
+	fs.StringVar(&params.CategoryPrefix, "category", "", "カテゴリーの前方一致")
+	fs.StringVar(&params.DateField, "date-field", "", "日付範囲の対象（created・updated、デフォルト: created）")
+	fs.StringVar(&params.DateFrom, "from", "", "日付範囲の開始日（YYYY-MM-DD形式）")
+	fs.StringVar(&params.DateTo, "to", "", "日付範囲の終了日（YYYY-MM-DD形式）")
+	fs.StringVar(&params.Sort, "sort", "", "並び順の基準（updated・created・number・stars・watches・comments・best_match）")
+	fs.StringVar(&params.Order, "order", "", "並び順（desc・asc）")
+	fs.IntVar(&params.Page, "page", 0, "ページ番号")
+	fs.IntVar(&params.PerPage, "per-page", 0, "1ページあたりの件数")
+	fs.StringVar(&params.Team, "team", "", "検索対象のチーム（省略時はデフォルトのチーム）")
+	asJSON := fs.Bool("json", false, "結果をJSONで出力する")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	params.Keywords = append(keywords, positional...)
+	params.Tags = tags
+
+	_, esaClient, err := env.client(params.Team)
+	if err != nil {
+		return err
+	}
+
+	result, err := searchPosts(ctx, &params, esaClient)
+	if err != nil {
+		return err
+	}
+	return env.print(*asJSON, result, formatSearchResponse(result))
+}
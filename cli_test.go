@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCommandEnv はモックのクライアントと固定した時刻でサブコマンドの実行環境を作る
+func newTestCommandEnv(t *testing.T, esaClient EsaClientInterface, now time.Time, stdin string) (*commandEnv, *bytes.Buffer) {
+	t.Helper()
+	clock := NewMockClock(t)
+	clock.EXPECT().Now().Return(now).Maybe()
+
+	stdout := &bytes.Buffer{}
+	return &commandEnv{
+		config: EsaConfig{TeamName: "test-team", AccessToken: "test-token"},
+		newClient: func(team string) (EsaClientInterface, error) {
+			return esaClient, nil
+		},
+		stdin:  strings.NewReader(stdin),
+		stdout: stdout,
+		stderr: &bytes.Buffer{},
+		clock:  clock,
+	}, stdout
+}
+
+func TestRunCommand(t *testing.T) {
+	// テスト用の現在時刻を固定
+	fixedTime := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+	createdPost := &EsaPost{Number: 123, Name: "日報", Category: "日報/2025/05/03"}
+
+	t.Run("postは引数のテキストを投稿する", func(t *testing.T) {
+		resetDebounce()
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "リリース作業 完了", "", fixedTime).Return(createdPost, nil)
+
+		// オプションはテキストの後ろにも書ける
+		env, stdout := newTestCommandEnv(t, mockEsaClient, fixedTime, "")
+		err := runCommand(context.TODO(), env, []string{"post", "#times-esa リリース作業", "完了", "--yes"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "日報を投稿しました（#123 日報/2025/05/03/日報）\n", stdout.String())
+	})
+
+	t.Run("postはテキストを省略すると標準入力から読む", func(t *testing.T) {
+		resetDebounce()
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "fix: typo\n\n本文", "", fixedTime).Return(createdPost, nil)
+
+		env, _ := newTestCommandEnv(t, mockEsaClient, fixedTime, "fix: typo\n\n本文\n")
+		require.NoError(t, runCommand(context.TODO(), env, []string{"post", "--yes"}))
+
+		// MCPのツールと同じデバウンスで、同じ内容の投稿は拒否する
+		env, _ = newTestCommandEnv(t, mockEsaClient, fixedTime, "fix: typo\n\n本文\n")
+		err := runCommand(context.TODO(), env, []string{"post", "--yes", "-"})
+		assert.ErrorIs(t, err, ErrDebounced)
+	})
+
+	t.Run("postは--yesがなければ投稿しない", func(t *testing.T) {
+		resetDebounce()
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		env, _ := newTestCommandEnv(t, mockEsaClient, fixedTime, "")
+		err := runCommand(context.TODO(), env, []string{"post", "テスト"})
+		assert.ErrorIs(t, err, ErrNotConfirmed)
+		assert.Contains(t, err.Error(), "--yes")
+	})
+
+	t.Run("postは保留していた投稿を先に日報に書く", func(t *testing.T) {
+		resetDebounce()
+		outbox := &Outbox{store: &memoryOutboxStore{}}
+		queuedAt := time.Date(2025, 5, 3, 9, 0, 0, 0, time.Local)
+		queued, err := outbox.Enqueue(OutboxItem{Text: "オフラインで書いた内容", Category: "日報/2025/05/03", CreatedAt: queuedAt})
+		require.NoError(t, err)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "オフラインで書いた内容", "", queuedAt).Return(createdPost, nil)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(createdPost, nil)
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, createdPost, "新しい内容", "", fixedTime).Return(createdPost, nil)
+
+		env, stdout := newTestCommandEnv(t, mockEsaClient, fixedTime, "")
+		stderr := &bytes.Buffer{}
+		env.stderr = stderr
+		env.outbox = outbox
+		require.NoError(t, runCommand(context.TODO(), env, []string{"post", "--yes", "新しい内容"}))
+		assert.Equal(t, "日報を投稿しました（#123 日報/2025/05/03/日報）\n", stdout.String())
+		assert.Equal(t, "保留していた投稿（"+queued.ID+"）を2025-05-03 09:00の日報に書きました\n", stderr.String())
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("postは保留していた投稿をやり直せなかったことを表示する", func(t *testing.T) {
+		resetDebounce()
+		outbox := &Outbox{store: &memoryOutboxStore{}}
+		queuedAt := time.Date(2025, 5, 3, 9, 0, 0, 0, time.Local)
+		queued, err := outbox.Enqueue(OutboxItem{Text: "オフラインで書いた内容", Category: "日報/2025/05/03", CreatedAt: queuedAt})
+		require.NoError(t, err)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(nil, newToolError(ErrEsaUnavailable, "service_unavailable")).Once()
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(createdPost, nil)
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, createdPost, "新しい内容", "", fixedTime).Return(createdPost, nil)
+
+		env, _ := newTestCommandEnv(t, mockEsaClient, fixedTime, "")
+		stderr := &bytes.Buffer{}
+		env.stderr = stderr
+		env.outbox = outbox
+		require.NoError(t, runCommand(context.TODO(), env, []string{"post", "--yes", "新しい内容"}))
+		assert.Contains(t, stderr.String(), "保留している投稿（"+queued.ID+"）をやり直せませんでした: 投稿の検索に失敗しました: service_unavailable")
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	})
+
+	t.Run("読み取りや--yesのないpostでは保留した投稿を書かない", func(t *testing.T) {
+		outbox := &Outbox{store: &memoryOutboxStore{}}
+		_, err := outbox.Enqueue(OutboxItem{Text: "オフラインで書いた内容", Category: "日報/2025/05/03", CreatedAt: fixedTime})
+		require.NoError(t, err)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, time.Date(2025, 5, 2, 0, 0, 0, 0, time.Local)).Return("日報/2025/05/02", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/02").Return(nil, nil)
+
+		env, _ := newTestCommandEnv(t, mockEsaClient, fixedTime, "")
+		env.outbox = outbox
+		require.NoError(t, runCommand(context.TODO(), env, []string{"today", "--date", "2025-05-02"}))
+		assert.ErrorIs(t, runCommand(context.TODO(), env, []string{"post", "テスト"}), ErrNotConfirmed)
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	})
+
+	t.Run("todayは日報のエントリを表示する", func(t *testing.T) {
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, time.Date(2025, 5, 2, 0, 0, 0, 0, time.Local)).Return("日報/2025/05/02", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/02").Return(&EsaPost{
+			Number: 120,
+			BodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n<a id=\"0930\" href=\"#0930\">09:30</a> 朝の作業\n\n---",
+		}, nil)
+
+		env, stdout := newTestCommandEnv(t, mockEsaClient, fixedTime, "")
+		require.NoError(t, runCommand(context.TODO(), env, []string{"today", "--date", "2025-05-02"}))
+		assert.Equal(t, "2025-05-02の日報には2件のエントリがあります\n\n13:00 (#1300) 午後の作業\n\n09:30 (#0930) 朝の作業\n", stdout.String())
+	})
+
+	t.Run("searchはタグとキーワードで検索してJSONで出力する", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.Query().Get("q") == "ジェネリクス tag:go"
+		})).Return(jsonResponse(http.StatusOK, `{
+			"posts": [{"number": 7, "name": "Goのメモ", "category": "tech", "tags": ["go"], "body_md": "ジェネリクスについて"}],
+			"total_count": 1
+		}`), nil)
+		esaClient := NewEsaClient(mockHTTPClient, EsaConfig{TeamName: "test-team", AccessToken: "test-token"})
+
+		env, stdout := newTestCommandEnv(t, esaClient, fixedTime, "")
+		require.NoError(t, runCommand(context.TODO(), env, []string{"search", "--tag", "go", "ジェネリクス", "--json"}))
+
+		var result EsaSearchResponse
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		require.Len(t, result.Posts, 1)
+		assert.Equal(t, 7, result.Posts[0].Number)
+	})
+
+	t.Run("存在しないサブコマンドはエラー", func(t *testing.T) {
+		env, _ := newTestCommandEnv(t, NewMockEsaClientInterface(t), fixedTime, "")
+		err := runCommand(context.TODO(), env, []string{"delete"})
+		assert.ErrorContains(t, err, "サブコマンドdeleteはありません")
+	})
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		expectedPositional []string
+		expectedYes        bool
+	}{
+		{name: "オプションが先", args: []string{"--yes", "a", "b"}, expectedPositional: []string{"a", "b"}, expectedYes: true},
+		{name: "オプションが後", args: []string{"a", "--yes", "b"}, expectedPositional: []string{"a", "b"}, expectedYes: true},
+		{name: "--以降は位置引数", args: []string{"a", "--", "--yes"}, expectedPositional: []string{"a", "--yes"}},
+		{name: "引数なし", args: nil, expectedPositional: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			yes := fs.Bool("yes", false, "")
+
+			positional, err := parseInterspersed(fs, tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPositional, positional)
+			assert.Equal(t, tt.expectedYes, *yes)
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultHTTPTimeout はesa.io APIのリクエストのデフォルトのタイムアウト
+	defaultHTTPTimeout = 10 * time.Second
+
+	// defaultPostPrefix は投稿テキストの先頭から除去するデフォルトのプレフィックス
+	defaultPostPrefix = "#times-esa"
+)
+
+// DefaultConfig はデフォルト値を設定したEsaConfigを返す
+func DefaultConfig() EsaConfig {
+	return EsaConfig{
+		CategoryTemplate: defaultCategoryTemplate,
+		TitleTemplate:    defaultTitleTemplate,
+		Timeout:          defaultHTTPTimeout,
+		Prefix:           defaultPostPrefix,
+		Debounce:         defaultDebounceConfig,
+		Outbox: OutboxConfig{
+			FlushInterval: defaultOutboxFlushInterval,
+		},
+		Server: ServerConfig{
+			Transport: transportStdio,
+			Listen:    defaultListenAddr,
+		},
+	}
+}
+
+// ConfigFromEnv は環境変数からEsaConfigを生成する
+func ConfigFromEnv() EsaConfig {
+	config := DefaultConfig()
+	// 環境変数の値の形式が不正な場合はデフォルト値のまま使う
+	_ = applyEnv(&config, os.Getenv)
+	return config
+}
+
+// LoadConfig は設定ファイル・環境変数・コマンドライン引数の順に設定を重ねて読み込み、検証する
+// 後から読み込んだものが優先される（コマンドライン引数 > 環境変数 > 設定ファイル > デフォルト値）
+// オプションの後に続く引数（サブコマンドとその引数）はそのまま返す
+func LoadConfig(args []string) (EsaConfig, []string, error) {
+	return loadConfigWithCommand(args, os.Getenv)
+}
+
+// loadConfig は設定だけを読み込む（環境変数の取得方法を差し替え可能、テスト用）
+func loadConfig(args []string, getenv func(string) string) (EsaConfig, error) {
+	config, _, err := loadConfigWithCommand(args, getenv)
+	return config, err
+}
+
+// loadConfigWithCommand はLoadConfigの実装（環境変数の取得方法を差し替え可能、テスト用）
+func loadConfigWithCommand(args []string, getenv func(string) string) (EsaConfig, []string, error) {
+	flags, err := parseConfigFlags(args)
+	if err != nil {
+		return EsaConfig{}, nil, err
+	}
+
+	config := DefaultConfig()
+
+	// 設定ファイル（明示的に指定された場合のみ、存在しないことをエラーにする）
+	path, explicit := flags.configPath, flags.configPath != ""
+	if !explicit {
+		path, explicit = getenv("ESA_CONFIG_FILE"), getenv("ESA_CONFIG_FILE") != ""
+	}
+	if !explicit {
+		path = defaultConfigPath(getenv)
+	}
+	if path != "" {
+		if err := applyConfigFile(&config, path); err != nil {
+			if explicit || !errors.Is(err, os.ErrNotExist) {
+				return EsaConfig{}, nil, err
+			}
+		}
+	}
+
+	// 環境変数
+	if err := applyEnv(&config, getenv); err != nil {
+		return EsaConfig{}, nil, err
+	}
+
+	// コマンドライン引数
+	flags.apply(&config)
+
+	if err := config.Validate(); err != nil {
+		return EsaConfig{}, nil, err
+	}
+	return config, flags.args, nil
+}
+
+// defaultConfigPath はデフォルトの設定ファイルのパス（$XDG_CONFIG_HOME/times-esa/config.yaml）を返す
+func defaultConfigPath(getenv func(string) string) string {
+	dir := getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home := getenv("HOME")
+		if home == "" {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "times-esa", "config.yaml")
+}
+
+// applyConfigFile はYAMLの設定ファイルを読み込み、書かれている項目だけを上書きする
+func applyConfigFile(config *EsaConfig, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("設定ファイル %s の解析に失敗: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv は設定されている環境変数だけを上書きする
+func applyEnv(config *EsaConfig, getenv func(string) string) error {
+	stringFields := map[string]*string{
+		"ESA_TEAM_NAME":         &config.TeamName,
+		"ESA_ACCESS_TOKEN":      &config.AccessToken,
+		"ESA_CATEGORY_TEMPLATE": &config.CategoryTemplate,
+		"ESA_TITLE_TEMPLATE":    &config.TitleTemplate,
+		"ESA_BODY_TEMPLATE":     &config.BodyTemplate,
+		"ESA_TEMPLATE_CATEGORY": &config.TemplateCategory,
+		"ESA_SCREEN_NAME":       &config.ScreenName,
+		"ESA_ENTRY_PLACEMENT":   &config.EntryPlacement,
+		"ESA_POST_PREFIX":       &config.Prefix,
+		"ESA_DEBOUNCE_STORE":    &config.DebounceStore,
+		"ESA_DEBOUNCE_METRIC":   &config.Debounce.Metric,
+		"ESA_DUPLICATE_POLICY":  &config.DuplicatePolicy,
+		"ESA_OUTBOX_STORE":      &config.Outbox.Store,
+		"ESA_TIME_ZONE":         &config.TimeZone,
+		"ESA_MCP_TRANSPORT":     &config.Server.Transport,
+		"ESA_MCP_LISTEN":        &config.Server.Listen,
+		"ESA_MCP_AUTH_TOKEN":    &config.Server.AuthToken,
+	}
+	for key, field := range stringFields {
+		if value := getenv(key); value != "" {
+			*field = value
+		}
+	}
+
+	if value := getenv("ESA_TAGS"); value != "" {
+		config.Tags = splitList(value)
+	}
+
+	durations := map[string]*time.Duration{
+		"ESA_TIMEOUT":               &config.Timeout,
+		"ESA_DEBOUNCE_DURATION":     &config.Debounce.Duration,
+		"ESA_OUTBOX_FLUSH_INTERVAL": &config.Outbox.FlushInterval,
+	}
+	for key, field := range durations {
+		if value := getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("環境変数%sの値が不正です: %w", key, err)
+			}
+			*field = d
+		}
+	}
+
+	if value := getenv("ESA_DEBOUNCE_REMOTE"); value != "" {
+		remote, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("環境変数ESA_DEBOUNCE_REMOTEの値が不正です: %w", err)
+		}
+		config.Debounce.Remote = remote
+	}
+
+	if value := getenv("ESA_TEMPLATE_POST_ID"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("環境変数ESA_TEMPLATE_POST_IDの値が不正です: %w", err)
+		}
+		config.TemplatePostID = id
+	}
+
+	if value := getenv("ESA_DAY_ROLLOVER_HOUR"); value != "" {
+		hour, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("環境変数ESA_DAY_ROLLOVER_HOURの値が不正です: %w", err)
+		}
+		config.DayRolloverHour = hour
+	}
+
+	if value := getenv("ESA_DEBOUNCE_SIMILARITY_THRESHOLD"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("環境変数ESA_DEBOUNCE_SIMILARITY_THRESHOLDの値が不正です: %w", err)
+		}
+		config.Debounce.SimilarityThreshold = threshold
+	}
+
+	return applyTeamsEnv(config, getenv)
+}
+
+// configFlags はコマンドライン引数で指定された設定
+type configFlags struct {
+	set        map[string]bool
+	configPath string
+	values     EsaConfig
+	tags       string
+	args       []string // オプションの後に続く引数
+}
+
+// parseConfigFlags はコマンドライン引数を解析する
+func parseConfigFlags(args []string) (*configFlags, error) {
+	flags := &configFlags{set: map[string]bool{}}
+
+	fs := flag.NewFlagSet("times_esa_mcp_server", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "使い方: times_esa_mcp_server [オプション] [サブコマンド]\n\nサブコマンドを省略するとMCPサーバーとして起動します\n\nサブコマンド:\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(fs.Output(), "  %-8s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintf(fs.Output(), "\nオプション:\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&flags.configPath, "config", "", "設定ファイルのパス（デフォルト: ~/.config/times-esa/config.yaml）")
+	fs.StringVar(&flags.values.TeamName, "team", "", "esa.ioのチーム名")
+	fs.StringVar(&flags.values.DefaultTeam, "default-team", "", "teamを省略したときに使うプロファイル名")
+	fs.StringVar(&flags.values.CategoryTemplate, "category-template", "", "日報のカテゴリーのテンプレート")
+	fs.StringVar(&flags.values.TitleTemplate, "title-template", "", "日報のタイトルのテンプレート")
+	fs.StringVar(&flags.values.BodyTemplate, "body-template", "", "日報の作成時の本文のテンプレート（見出しの下にsectionで投稿できる）")
+	fs.IntVar(&flags.values.TemplatePostID, "template-post-id", 0, "日報の作成時の本文に使うesa.ioのテンプレートの投稿の番号")
+	fs.StringVar(&flags.values.TemplateCategory, "template-category", "", "日報の作成時の本文に使うesa.ioのテンプレートの投稿のカテゴリー")
+	fs.StringVar(&flags.tags, "tags", "", "日報の作成時に付けるタグ（カンマ区切り）")
+	fs.StringVar(&flags.values.ScreenName, "screen-name", "", "テンプレートで使うscreen_name")
+	fs.StringVar(&flags.values.EntryPlacement, "entry-placement", "", "日報にエントリを書く位置（"+strings.Join(entryPlacements, "・")+"、デフォルト: "+entryPlacementPrepend+"）")
+	fs.StringVar(&flags.values.Prefix, "prefix", "", "投稿テキストの先頭から除去するプレフィックス")
+	fs.DurationVar(&flags.values.Timeout, "timeout", 0, "esa.io APIのリクエストのタイムアウト")
+	fs.DurationVar(&flags.values.Debounce.Duration, "debounce-duration", 0, "同じ内容の投稿を拒否する時間")
+	fs.Float64Var(&flags.values.Debounce.SimilarityThreshold, "debounce-threshold", 0, "同じ内容とみなす類似度のしきい値（0.0〜1.0）")
+	fs.BoolVar(&flags.values.Debounce.Remote, "debounce-remote", false, "既存の日報のエントリとも比較して同じ内容の投稿を拒否する")
+	fs.StringVar(&flags.values.Debounce.Metric, "debounce-metric", "", "同じ内容かどうかを判定する類似度の計算方法（"+strings.Join(SimilarityMetricNames(), "・")+"）")
+	fs.StringVar(&flags.values.DuplicatePolicy, "duplicate-policy", "", "同じ日の日報が複数存在する場合の扱い（"+strings.Join(duplicatePolicies, "・")+"）")
+	fs.StringVar(&flags.values.TimeZone, "time-zone", "", "日報の日付と投稿時刻を求めるIANAのタイムゾーン（例: Asia/Tokyo、デフォルト: プロセスのタイムゾーン）")
+	fs.IntVar(&flags.values.DayRolloverHour, "day-rollover-hour", 0, "日付が変わる時（0〜23、4なら4時より前の投稿は前日の日報に書く）")
+	fs.StringVar(&flags.values.Outbox.Store, "outbox-store", "", "esa.ioに接続できない間の投稿を保留するファイル（memoryならプロセス内のみ、offなら保留しない）")
+	fs.DurationVar(&flags.values.Outbox.FlushInterval, "outbox-flush-interval", 0, "保留した投稿をやり直す間隔")
+	fs.StringVar(&flags.values.Server.Transport, "transport", "", "MCPクライアントとの通信方法（stdio・http）")
+	fs.StringVar(&flags.values.Server.Listen, "listen", "", "httpの場合に待ち受けるアドレス（デフォルト: "+defaultListenAddr+"）")
+	fs.StringVar(&flags.values.Server.AuthToken, "auth-token", "", "httpの場合にMCPクライアントに要求するBearerトークン")
+	fs.StringVar(&flags.values.DebounceStore, "debounce-store", "", "デバウンスの履歴の保存先のファイル（memoryならプロセス内のみ）")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		flags.set[f.Name] = true
+	})
+	flags.args = fs.Args()
+	return flags, nil
+}
+
+// apply は指定されたコマンドライン引数だけを上書きする
+func (f *configFlags) apply(config *EsaConfig) {
+	if f.set["team"] {
+		config.TeamName = f.values.TeamName
+	}
+	if f.set["default-team"] {
+		config.DefaultTeam = f.values.DefaultTeam
+	}
+	if f.set["category-template"] {
+		config.CategoryTemplate = f.values.CategoryTemplate
+	}
+	if f.set["title-template"] {
+		config.TitleTemplate = f.values.TitleTemplate
+	}
+	if f.set["body-template"] {
+		config.BodyTemplate = f.values.BodyTemplate
+	}
+	if f.set["template-post-id"] {
+		config.TemplatePostID = f.values.TemplatePostID
+	}
+	if f.set["template-category"] {
+		config.TemplateCategory = f.values.TemplateCategory
+	}
+	if f.set["tags"] {
+		config.Tags = splitList(f.tags)
+	}
+	if f.set["screen-name"] {
+		config.ScreenName = f.values.ScreenName
+	}
+	if f.set["entry-placement"] {
+		config.EntryPlacement = f.values.EntryPlacement
+	}
+	if f.set["prefix"] {
+		config.Prefix = f.values.Prefix
+	}
+	if f.set["timeout"] {
+		config.Timeout = f.values.Timeout
+	}
+	if f.set["debounce-duration"] {
+		config.Debounce.Duration = f.values.Debounce.Duration
+	}
+	if f.set["debounce-threshold"] {
+		config.Debounce.SimilarityThreshold = f.values.Debounce.SimilarityThreshold
+	}
+	if f.set["debounce-remote"] {
+		config.Debounce.Remote = f.values.Debounce.Remote
+	}
+	if f.set["debounce-metric"] {
+		config.Debounce.Metric = f.values.Debounce.Metric
+	}
+	if f.set["debounce-store"] {
+		config.DebounceStore = f.values.DebounceStore
+	}
+	if f.set["duplicate-policy"] {
+		config.DuplicatePolicy = f.values.DuplicatePolicy
+	}
+	if f.set["time-zone"] {
+		config.TimeZone = f.values.TimeZone
+	}
+	if f.set["day-rollover-hour"] {
+		config.DayRolloverHour = f.values.DayRolloverHour
+	}
+	if f.set["outbox-store"] {
+		config.Outbox.Store = f.values.Outbox.Store
+	}
+	if f.set["outbox-flush-interval"] {
+		config.Outbox.FlushInterval = f.values.Outbox.FlushInterval
+	}
+	if f.set["transport"] {
+		config.Server.Transport = f.values.Server.Transport
+	}
+	if f.set["listen"] {
+		config.Server.Listen = f.values.Server.Listen
+	}
+	if f.set["auth-token"] {
+		config.Server.AuthToken = f.values.Server.AuthToken
+	}
+}
+
+// Validate は設定値が正しいかどうかを検証する
+// チーム名とアクセストークンは投稿時に検証するため、ここでは確認しない
+func (c EsaConfig) Validate() error {
+	errs := c.validateProfile(func(string) bool { return true })
+	errs = append(errs, c.Server.validate()...)
+	if _, err := NewDayBoundary(c.TimeZone, c.DayRolloverHour); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Outbox.FlushInterval <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.flush_intervalは正の値を指定してください: %s", c.Outbox.FlushInterval))
+	}
+
+	if c.DefaultTeam != "" {
+		if _, ok := c.Teams[c.DefaultTeam]; !ok {
+			errs = append(errs, fmt.Errorf("default_teamのチーム%sは設定されていません", c.DefaultTeam))
+		}
+	}
+	// 共通の設定は上で検証したため、プロファイルごとには上書きした項目だけを検証する
+	for _, name := range c.TeamNames() {
+		profile, err := c.Profile(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, err := range profile.validateProfile(c.Teams[name].overrides) {
+			errs = append(errs, fmt.Errorf("teams.%s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("設定が不正です: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate はMCPクライアントとの通信の設定を検証する
+func (c ServerConfig) validate() []error {
+	var errs []error
+
+	switch c.Transport {
+	case "", transportStdio:
+	case transportHTTP:
+		if c.Listen == "" {
+			errs = append(errs, fmt.Errorf("server.listenを指定してください"))
+		}
+		// esa.ioのアクセストークンで投稿できてしまうため、認証なしでは待ち受けない
+		if c.AuthToken == "" {
+			errs = append(errs, fmt.Errorf("server.transportがhttpの場合はserver.auth_tokenを指定してください"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("server.transportは%sまたは%sを指定してください: %s", transportStdio, transportHTTP, c.Transport))
+	}
+	return errs
+}
+
+// validateProfile はプロファイルごとに異なりうる設定値のうち、checkedがtrueを返す項目（YAMLのキー）を検証する
+func (c EsaConfig) validateProfile(checked func(key string) bool) []error {
+	var errs []error
+
+	if checked("timeout") && c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("timeoutは正の値を指定してください: %s", c.Timeout))
+	}
+	if checked("debounce.duration") && c.Debounce.Duration < 0 {
+		errs = append(errs, fmt.Errorf("debounce.durationは0以上を指定してください: %s", c.Debounce.Duration))
+	}
+	if checked("debounce.similarity_threshold") && (c.Debounce.SimilarityThreshold < 0 || c.Debounce.SimilarityThreshold > 1) {
+		errs = append(errs, fmt.Errorf("debounce.similarity_thresholdは0.0〜1.0で指定してください: %g", c.Debounce.SimilarityThreshold))
+	}
+	if checked("debounce.metric") {
+		if _, err := NewSimilarityMetric(c.Debounce.Metric); err != nil {
+			errs = append(errs, fmt.Errorf("debounce.metricが不正です: %w", err))
+		}
+	}
+	if checked("entry_placement") && c.EntryPlacement != "" && !slices.Contains(entryPlacements, c.EntryPlacement) {
+		errs = append(errs, fmt.Errorf("entry_placementは%sのいずれかを指定してください: %s", strings.Join(entryPlacements, "・"), c.EntryPlacement))
+	}
+	if checked("duplicate_policy") && c.DuplicatePolicy != "" && !slices.Contains(duplicatePolicies, c.DuplicatePolicy) {
+		errs = append(errs, fmt.Errorf("duplicate_policyは%sのいずれかを指定してください: %s", strings.Join(duplicatePolicies, "・"), c.DuplicatePolicy))
+	}
+
+	if checked("body_template") {
+		if c.TemplatePostID < 0 {
+			errs = append(errs, fmt.Errorf("template_post_idは正の値を指定してください: %d", c.TemplatePostID))
+		}
+		bodySources := 0
+		for _, set := range []bool{c.BodyTemplate != "", c.TemplatePostID != 0, c.TemplateCategory != ""} {
+			if set {
+				bodySources++
+			}
+		}
+		if bodySources > 1 {
+			errs = append(errs, fmt.Errorf("body_template・template_post_id・template_categoryはどれか1つを指定してください"))
+		}
+	}
+
+	// テンプレートはサンプルの値で展開できるかを確認する
+	sample := NewDailyReportTemplateData(time.Now(), "screen_name")
+	if checked("category_template") {
+		if _, err := renderDailyReportTemplate("カテゴリー", c.categoryTemplate(), sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if checked("title_template") {
+		if _, err := renderDailyReportTemplate("タイトル", c.titleTemplate(), sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if checked("body_template") {
+		if _, err := renderDailyReportTemplate("本文", c.BodyTemplate, sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// overrides はプロファイルで設定の項目key（YAMLのキー）を上書きしているかどうかを返す
+// 本文のテンプレート（body_template・template_post_id・template_category）はまとめて置き換えるため、body_templateで判定する
+func (p TeamProfile) overrides(key string) bool {
+	switch key {
+	case "category_template":
+		return p.CategoryTemplate != ""
+	case "title_template":
+		return p.TitleTemplate != ""
+	case "body_template":
+		return p.BodyTemplate != "" || p.TemplatePostID != 0 || p.TemplateCategory != ""
+	case "entry_placement":
+		return p.EntryPlacement != ""
+	case "debounce.duration":
+		return p.Debounce != nil && p.Debounce.Duration != nil
+	case "debounce.similarity_threshold":
+		return p.Debounce != nil && p.Debounce.SimilarityThreshold != nil
+	case "debounce.metric":
+		return p.Debounce != nil && p.Debounce.Metric != nil
+	}
+	return false
+}
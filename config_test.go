@@ -0,0 +1,287 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envMap はテスト用の環境変数を返すgetenv関数を作る
+func envMap(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// writeConfigFile はテスト用の設定ファイルを書き出してパスを返す
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	configFile := `
+team_name: file-team
+access_token: file-token
+category_template: "times/{{.ScreenName}}/{{.Year}}/{{.Month}}/{{.Day}}"
+title_template: "日報 {{.Date}}"
+tags: [日報, times]
+screen_name: file_user
+timeout: 30s
+prefix: "#nippo"
+debounce:
+  duration: 10m
+  similarity_threshold: 0.8
+time_zone: Asia/Tokyo
+day_rollover_hour: 4
+outbox:
+  flush_interval: 30s
+server:
+  transport: http
+  auth_token: file-secret
+`
+
+	t.Run("デフォルト値", func(t *testing.T) {
+		config, err := loadConfig(nil, envMap(map[string]string{}))
+		require.NoError(t, err)
+		assert.Equal(t, DefaultConfig(), config)
+		assert.Equal(t, 10*time.Second, config.Timeout)
+		assert.Equal(t, "#times-esa", config.Prefix)
+		assert.Equal(t, 300*time.Second, config.Debounce.Duration)
+		assert.Equal(t, 0.9, config.Debounce.SimilarityThreshold)
+	})
+
+	t.Run("設定ファイル", func(t *testing.T) {
+		path := writeConfigFile(t, configFile)
+
+		config, err := loadConfig([]string{"-config", path}, envMap(map[string]string{}))
+		require.NoError(t, err)
+		assert.Equal(t, EsaConfig{
+			TeamName:         "file-team",
+			AccessToken:      "file-token",
+			CategoryTemplate: "times/{{.ScreenName}}/{{.Year}}/{{.Month}}/{{.Day}}",
+			TitleTemplate:    "日報 {{.Date}}",
+			Tags:             []string{"日報", "times"},
+			ScreenName:       "file_user",
+			Timeout:          30 * time.Second,
+			Prefix:           "#nippo",
+			Debounce: DebounceConfig{
+				Duration:            10 * time.Minute,
+				SimilarityThreshold: 0.8,
+			},
+			TimeZone:        "Asia/Tokyo",
+			DayRolloverHour: 4,
+			Outbox: OutboxConfig{
+				FlushInterval: 30 * time.Second,
+			},
+			Server: ServerConfig{
+				Transport: "http",
+				Listen:    defaultListenAddr,
+				AuthToken: "file-secret",
+			},
+		}, config)
+	})
+
+	t.Run("設定ファイルに書かれていない項目はデフォルト値", func(t *testing.T) {
+		path := writeConfigFile(t, "team_name: file-team\n")
+
+		config, err := loadConfig(nil, envMap(map[string]string{"ESA_CONFIG_FILE": path}))
+		require.NoError(t, err)
+		assert.Equal(t, "file-team", config.TeamName)
+		assert.Equal(t, defaultHTTPTimeout, config.Timeout)
+		assert.Equal(t, defaultDebounceConfig, config.Debounce)
+	})
+
+	t.Run("XDG_CONFIG_HOMEの設定ファイルを読み込む", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "times-esa"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "times-esa", "config.yaml"), []byte("team_name: xdg-team\n"), 0o600))
+
+		config, err := loadConfig(nil, envMap(map[string]string{"XDG_CONFIG_HOME": dir}))
+		require.NoError(t, err)
+		assert.Equal(t, "xdg-team", config.TeamName)
+	})
+
+	t.Run("優先順位（コマンドライン引数 > 環境変数 > 設定ファイル）", func(t *testing.T) {
+		path := writeConfigFile(t, configFile)
+		env := envMap(map[string]string{
+			"ESA_TEAM_NAME":                     "env-team",
+			"ESA_TAGS":                          "env1, env2",
+			"ESA_TIMEOUT":                       "20s",
+			"ESA_DEBOUNCE_SIMILARITY_THRESHOLD": "0.7",
+			"ESA_DEBOUNCE_REMOTE":               "true",
+			"ESA_DEBOUNCE_METRIC":               "ngram_jaccard",
+			"ESA_DUPLICATE_POLICY":              "merge",
+			"ESA_TIME_ZONE":                     "UTC",
+			"ESA_DAY_ROLLOVER_HOUR":             "5",
+			"ESA_TEMPLATE_POST_ID":              "42",
+			"ESA_MCP_LISTEN":                    "0.0.0.0:9000",
+			"ESA_MCP_AUTH_TOKEN":                "env-secret",
+		})
+		args := []string{"-config", path, "-timeout", "5s", "-prefix", "", "-debounce-metric", "token_cosine", "--listen=:9090", "-day-rollover-hour", "3"}
+
+		config, err := loadConfig(args, env)
+		require.NoError(t, err)
+		assert.Equal(t, "env-team", config.TeamName)           // 環境変数が設定ファイルより優先
+		assert.Equal(t, "file-token", config.AccessToken)      // 設定ファイルのみ
+		assert.Equal(t, []string{"env1", "env2"}, config.Tags) // 環境変数が設定ファイルより優先
+		assert.Equal(t, 5*time.Second, config.Timeout)         // コマンドライン引数が最優先
+		assert.Equal(t, "", config.Prefix)                     // コマンドライン引数で空に上書き
+		assert.Equal(t, 0.7, config.Debounce.SimilarityThreshold)
+		assert.Equal(t, 10*time.Minute, config.Debounce.Duration)
+		assert.True(t, config.Debounce.Remote)
+		assert.Equal(t, "token_cosine", config.Debounce.Metric) // コマンドライン引数が最優先
+		assert.Equal(t, "merge", config.DuplicatePolicy)
+		assert.Equal(t, "UTC", config.TimeZone)    // 環境変数が設定ファイルより優先
+		assert.Equal(t, 3, config.DayRolloverHour) // コマンドライン引数が最優先
+		assert.Equal(t, 42, config.TemplatePostID)
+		assert.Equal(t, ServerConfig{Transport: "http", Listen: ":9090", AuthToken: "env-secret"}, config.Server)
+	})
+
+	t.Run("オプションの後に続く引数はサブコマンドとして返す", func(t *testing.T) {
+		config, args, err := loadConfigWithCommand([]string{"-team", "flag-team", "post", "--yes", "テスト"}, envMap(map[string]string{}))
+		require.NoError(t, err)
+		assert.Equal(t, "flag-team", config.TeamName)
+		assert.Equal(t, []string{"post", "--yes", "テスト"}, args)
+	})
+
+	t.Run("デフォルトの設定ファイルが存在しなくてもエラーにしない", func(t *testing.T) {
+		_, err := loadConfig(nil, envMap(map[string]string{"HOME": t.TempDir()}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("指定した設定ファイルが存在しない場合はエラー", func(t *testing.T) {
+		_, err := loadConfig([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, envMap(map[string]string{}))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "設定ファイルの読み込みに失敗")
+	})
+
+	t.Run("設定ファイルの未知の項目はエラー", func(t *testing.T) {
+		path := writeConfigFile(t, "team: typo\n")
+
+		_, err := loadConfig([]string{"-config", path}, envMap(map[string]string{}))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "設定ファイル")
+	})
+
+	t.Run("環境変数の形式が不正な場合はエラー", func(t *testing.T) {
+		_, err := loadConfig(nil, envMap(map[string]string{"ESA_DEBOUNCE_DURATION": "5分"}))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ESA_DEBOUNCE_DURATION")
+
+		_, err = loadConfig(nil, envMap(map[string]string{"ESA_TEAMS": "work", "ESA_WORK_TEMPLATE_POST_ID": "日報"}))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ESA_WORK_TEMPLATE_POST_ID")
+	})
+}
+
+func TestEsaConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(c *EsaConfig)
+		expectedError string
+	}{
+		{
+			name:          "timeoutが0",
+			modify:        func(c *EsaConfig) { c.Timeout = 0 },
+			expectedError: "timeout",
+		},
+		{
+			name:          "debounce.durationが負",
+			modify:        func(c *EsaConfig) { c.Debounce.Duration = -time.Second },
+			expectedError: "debounce.duration",
+		},
+		{
+			name:          "similarity_thresholdが範囲外",
+			modify:        func(c *EsaConfig) { c.Debounce.SimilarityThreshold = 1.5 },
+			expectedError: "debounce.similarity_threshold",
+		},
+		{
+			name:          "類似度の計算方法が存在しない",
+			modify:        func(c *EsaConfig) { c.Debounce.Metric = "soundex" },
+			expectedError: "debounce.metric",
+		},
+		{
+			name:          "エントリを書く位置が存在しない",
+			modify:        func(c *EsaConfig) { c.EntryPlacement = "bottom" },
+			expectedError: "entry_placement",
+		},
+		{
+			name:          "重複した日報の扱いが存在しない",
+			modify:        func(c *EsaConfig) { c.DuplicatePolicy = "latest" },
+			expectedError: "duplicate_policy",
+		},
+		{
+			name:          "タイムゾーンが存在しない",
+			modify:        func(c *EsaConfig) { c.TimeZone = "Asia/Edo" },
+			expectedError: "time_zone",
+		},
+		{
+			name:          "日付が変わる時が範囲外",
+			modify:        func(c *EsaConfig) { c.DayRolloverHour = 24 },
+			expectedError: "day_rollover_hour",
+		},
+		{
+			name:          "アウトボックスの間隔が0",
+			modify:        func(c *EsaConfig) { c.Outbox.FlushInterval = 0 },
+			expectedError: "outbox.flush_interval",
+		},
+		{
+			name:          "トランスポートが存在しない",
+			modify:        func(c *EsaConfig) { c.Server.Transport = "sse" },
+			expectedError: "server.transport",
+		},
+		{
+			name:          "httpでBearerトークンがない",
+			modify:        func(c *EsaConfig) { c.Server.Transport = "http" },
+			expectedError: "server.auth_token",
+		},
+		{
+			name:          "カテゴリーのテンプレートが不正",
+			modify:        func(c *EsaConfig) { c.CategoryTemplate = "日報/{{.Year" },
+			expectedError: "カテゴリーのテンプレートの解析に失敗",
+		},
+		{
+			name:          "タイトルのテンプレートが存在しない値を参照",
+			modify:        func(c *EsaConfig) { c.TitleTemplate = "{{.Hour}}" },
+			expectedError: "タイトルのテンプレートの展開に失敗",
+		},
+		{
+			name:          "本文のテンプレートが不正",
+			modify:        func(c *EsaConfig) { c.BodyTemplate = "# {{.Date}の日報" },
+			expectedError: "本文のテンプレートの解析に失敗",
+		},
+		{
+			name:          "テンプレートの投稿の番号が負",
+			modify:        func(c *EsaConfig) { c.TemplatePostID = -1 },
+			expectedError: "template_post_id",
+		},
+		{
+			name: "本文のテンプレートとesa.ioのテンプレートの投稿を両方指定",
+			modify: func(c *EsaConfig) {
+				c.BodyTemplate = "## やったこと"
+				c.TemplateCategory = "Templates/日報"
+			},
+			expectedError: "どれか1つ",
+		},
+	}
+
+	assert.NoError(t, DefaultConfig().Validate())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config)
+
+			err := config.Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxDayRolloverHour は日付が変わる時刻として指定できる最大の時
+const maxDayRolloverHour = 23
+
+// DayBoundary は日報の1日の区切り（タイムゾーンと日付が変わる時刻）
+type DayBoundary struct {
+	// 日報の日付と投稿時刻を求めるタイムゾーン
+	Location *time.Location
+	// この時より前の投稿は前日の日報に書く（0なら0時で日付が変わる）
+	RolloverHour int
+}
+
+// defaultDayBoundary はプロセスのタイムゾーンの0時で日付が変わる区切り
+var defaultDayBoundary = DayBoundary{Location: time.Local}
+
+// DayBoundaryReporter は設定された日報の1日の区切りを報告するインターフェース
+type DayBoundaryReporter interface {
+	DayBoundary() DayBoundary
+}
+
+// NewDayBoundary はIANAのタイムゾーン名（空ならプロセスのタイムゾーン）と日付が変わる時からDayBoundaryを作る
+func NewDayBoundary(timeZone string, rolloverHour int) (DayBoundary, error) {
+	if rolloverHour < 0 || rolloverHour > maxDayRolloverHour {
+		return DayBoundary{}, fmt.Errorf("day_rollover_hourは0〜%dで指定してください: %d", maxDayRolloverHour, rolloverHour)
+	}
+	location := time.Local
+	if timeZone != "" {
+		var err error
+		location, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return DayBoundary{}, fmt.Errorf("time_zoneが不正です: %w", err)
+		}
+	}
+	return DayBoundary{Location: location, RolloverHour: rolloverHour}, nil
+}
+
+// location は日報のタイムゾーンを返す（未設定ならプロセスのタイムゾーン）
+func (b DayBoundary) location() *time.Location {
+	if b.Location == nil {
+		return time.Local
+	}
+	return b.Location
+}
+
+// In はtを日報のタイムゾーンの時刻にする（投稿時刻の表示に使う）
+func (b DayBoundary) In(t time.Time) time.Time {
+	return t.In(b.location())
+}
+
+// ReportDay はtが属する日報の日の開始時刻（その日の日付が変わる時刻）を返す
+// 経過時間ではなく壁時計の時で判定するため、夏時間の切り替わる日も正しい日付になる
+func (b DayBoundary) ReportDay(t time.Time) time.Time {
+	t = b.In(t)
+	year, month, day := t.Date()
+	if t.Hour() < b.RolloverHour {
+		day--
+	}
+	return b.DayStart(year, month, day)
+}
+
+// DayStart は指定した日付の日報の開始時刻を返す（ReportDayに渡しても同じ日になる）
+// 夏時間の開始で日付が変わる時刻が存在しない日は、夏時間が始まった時刻を返す
+func (b DayBoundary) DayStart(year int, month time.Month, day int) time.Time {
+	start := time.Date(year, month, day, b.RolloverHour, 0, 0, 0, b.location())
+
+	// time.Dateは存在しない時刻を切り替わる前の時刻にすることがあるため、壁時計の時刻で比べる
+	wall := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+	if wall.Before(time.Date(year, month, day, b.RolloverHour, 0, 0, 0, time.UTC)) {
+		_, transition := start.ZoneBounds()
+		start = transition
+	}
+	return start
+}
+
+// dayBoundary は設定されたタイムゾーンと日付が変わる時刻の区切りを返す
+// 設定値はValidateで検証するため、不正な場合はデフォルトの区切りを使う
+func (c EsaConfig) dayBoundary() DayBoundary {
+	boundary, err := NewDayBoundary(c.TimeZone, c.DayRolloverHour)
+	if err != nil {
+		return defaultDayBoundary
+	}
+	return boundary
+}
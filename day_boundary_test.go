@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDayBoundary_ReportDay(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeZone     string
+		rolloverHour int
+		at           time.Time
+		expected     string
+	}{
+		{
+			name:     "UTCで動いていても日本時間の9時は当日",
+			timeZone: "Asia/Tokyo",
+			at:       time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC),
+			expected: "2025-05-03T00:00:00+09:00",
+		},
+		{
+			name:     "日本時間の0時前は前日",
+			timeZone: "Asia/Tokyo",
+			at:       time.Date(2025, 5, 3, 14, 59, 0, 0, time.UTC),
+			expected: "2025-05-03T00:00:00+09:00",
+		},
+		{
+			name:         "日付が変わる時刻より前は前日",
+			timeZone:     "Asia/Tokyo",
+			rolloverHour: 4,
+			at:           time.Date(2025, 5, 3, 16, 30, 0, 0, time.UTC), // 5/4 01:30 JST
+			expected:     "2025-05-03T04:00:00+09:00",
+		},
+		{
+			name:         "日付が変わる時刻ちょうどは当日",
+			timeZone:     "Asia/Tokyo",
+			rolloverHour: 4,
+			at:           time.Date(2025, 5, 3, 19, 0, 0, 0, time.UTC), // 5/4 04:00 JST
+			expected:     "2025-05-04T04:00:00+09:00",
+		},
+		{
+			name:         "夏時間が始まる日（経過時間で4時間戻すと前日になる時刻）",
+			timeZone:     "America/New_York",
+			rolloverHour: 4,
+			at:           time.Date(2025, 3, 9, 8, 30, 0, 0, time.UTC), // 3/9 04:30 EDT
+			expected:     "2025-03-09T04:00:00-04:00",
+		},
+		{
+			name:         "夏時間が始まる日の日付が変わる時刻より前",
+			timeZone:     "America/New_York",
+			rolloverHour: 4,
+			at:           time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC), // 3/9 03:30 EDT
+			expected:     "2025-03-08T04:00:00-05:00",
+		},
+		{
+			name:         "夏時間が終わる日に繰り返す時刻はどちらも前日",
+			timeZone:     "America/New_York",
+			rolloverHour: 2,
+			at:           time.Date(2025, 11, 2, 6, 30, 0, 0, time.UTC), // 11/2 2回目の01:30 EST
+			expected:     "2025-11-01T02:00:00-04:00",
+		},
+		{
+			name:         "夏時間が終わる日の日付が変わる時刻",
+			timeZone:     "America/New_York",
+			rolloverHour: 2,
+			at:           time.Date(2025, 11, 2, 7, 0, 0, 0, time.UTC), // 11/2 02:00 EST
+			expected:     "2025-11-02T02:00:00-05:00",
+		},
+		{
+			name:         "日付が変わる時刻が存在しない日は夏時間の開始時刻から",
+			timeZone:     "Europe/London",
+			rolloverHour: 1,
+			at:           time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC), // 3/30 02:30 BST
+			expected:     "2025-03-30T02:00:00+01:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			boundary, err := NewDayBoundary(tt.timeZone, tt.rolloverHour)
+			require.NoError(t, err)
+
+			day := boundary.ReportDay(tt.at)
+			assert.Equal(t, tt.expected, day.Format(time.RFC3339))
+			// 日報の開始時刻を渡しても同じ日になる
+			assert.True(t, day.Equal(boundary.ReportDay(day)))
+		})
+	}
+}
+
+func TestDayBoundary_DayStart(t *testing.T) {
+	boundary, err := NewDayBoundary("America/New_York", 2)
+	require.NoError(t, err)
+
+	// 2時が存在しない日は3時（夏時間）から始まる
+	start := boundary.DayStart(2025, 3, 9)
+	assert.Equal(t, "2025-03-09T03:00:00-04:00", start.Format(time.RFC3339))
+	assert.Equal(t, "2025-03-09", boundary.ReportDay(start).Format("2006-01-02"))
+	// 前日の日報は夏時間が始まる直前まで
+	assert.Equal(t, "2025-03-08", boundary.ReportDay(start.Add(-time.Second)).Format("2006-01-02"))
+}
+
+func TestNewDayBoundary(t *testing.T) {
+	boundary, err := NewDayBoundary("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, boundary.Location)
+
+	_, err = NewDayBoundary("Asia/Edo", 0)
+	assert.ErrorContains(t, err, "time_zone")
+
+	_, err = NewDayBoundary("Asia/Tokyo", 24)
+	assert.ErrorContains(t, err, "day_rollover_hour")
+
+	_, err = NewDayBoundary("Asia/Tokyo", -1)
+	assert.ErrorContains(t, err, "day_rollover_hour")
+}
+
+func TestDayBoundaryOf(t *testing.T) {
+	// esa.ioクライアントの設定の区切りを使う
+	client := NewEsaClient(nil, EsaConfig{TimeZone: "Asia/Tokyo", DayRolloverHour: 4})
+	boundary := dayBoundaryOf(client)
+	assert.Equal(t, "Asia/Tokyo", boundary.Location.String())
+	assert.Equal(t, 4, boundary.RolloverHour)
+
+	// 区切りを報告しないクライアントはデフォルトの区切り
+	assert.Equal(t, defaultDayBoundary, dayBoundaryOf(NewMockEsaClientInterface(t)))
+}
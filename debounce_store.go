@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// memoryDebounceStoreName はデバウンスの履歴をプロセス内だけで保持する指定
+const memoryDebounceStoreName = "memory"
+
+// DebounceStore はデバウンスの履歴（チームごとの最近の投稿テキスト）を保持するストア
+type DebounceStore interface {
+	// Update はチームの履歴をfnに渡し、fnが変更した履歴を保存する
+	// 読み込みから保存までは他の呼び出し（他のプロセスを含む）と排他的に行う
+	Update(team string, fn func(entries map[string]debounceEntry) error) error
+}
+
+// memoryDebounceStore はプロセス内のマップで履歴を保持するDebounceStore
+type memoryDebounceStore struct {
+	mu      sync.Mutex
+	entries map[string]map[string]debounceEntry
+}
+
+// newMemoryDebounceStore は新しいmemoryDebounceStoreを返す
+func newMemoryDebounceStore() *memoryDebounceStore {
+	return &memoryDebounceStore{entries: make(map[string]map[string]debounceEntry)}
+}
+
+func (s *memoryDebounceStore) Update(team string, fn func(entries map[string]debounceEntry) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.entries[team]
+	if entries == nil {
+		entries = make(map[string]debounceEntry)
+		s.entries[team] = entries
+	}
+	return fn(entries)
+}
+
+// fileDebounceStore はJSONファイルで履歴を保持するDebounceStore
+// updateLockedFileでファイルロックで排他するため、同時に動いている複数のサーバープロセスで同じ履歴を共有できる
+type fileDebounceStore struct {
+	path string
+
+	// 同じプロセス内の排他（ファイルロックはプロセス間の排他に使う）
+	mu sync.Mutex
+}
+
+// newFileDebounceStore は指定したパスのファイルに履歴を保存するfileDebounceStoreを返す
+func newFileDebounceStore(path string) *fileDebounceStore {
+	return &fileDebounceStore{path: path}
+}
+
+func (s *fileDebounceStore) Update(team string, fn func(entries map[string]debounceEntry) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return updateLockedFile(s.path, "デバウンスの履歴", func(data []byte) ([]byte, error) {
+		state := make(map[string]map[string]debounceEntry)
+		if len(data) > 0 {
+			// 壊れたファイルは空の履歴として扱い、次の保存で上書きする
+			if err := json.Unmarshal(data, &state); err != nil {
+				state = make(map[string]map[string]debounceEntry)
+			}
+		}
+
+		entries := state[team]
+		if entries == nil {
+			entries = make(map[string]debounceEntry)
+		}
+		if err := fn(entries); err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			state[team] = entries
+		} else {
+			delete(state, team)
+		}
+
+		data, err := json.Marshal(state)
+		if err != nil {
+			return nil, fmt.Errorf("デバウンスの履歴のJSON変換に失敗: %w", err)
+		}
+		return data, nil
+	})
+}
+
+// defaultDebounceStorePath はデバウンスの履歴のデフォルトの保存先（ユーザーのキャッシュディレクトリ）を返す
+func defaultDebounceStorePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "times-esa", "debounce.json"), nil
+}
+
+// NewDebounceStore は設定に応じたDebounceStoreを返す
+// "memory"ならプロセス内のみ、空ならユーザーのキャッシュディレクトリ、それ以外は指定したパスのファイルに保存する
+func NewDebounceStore(spec string) (DebounceStore, error) {
+	switch spec {
+	case memoryDebounceStoreName:
+		return newMemoryDebounceStore(), nil
+	case "":
+		path, err := defaultDebounceStorePath()
+		if err != nil {
+			return nil, fmt.Errorf("デバウンスの履歴の保存先を決められません: %w", err)
+		}
+		return newFileDebounceStore(path), nil
+	default:
+		return newFileDebounceStore(spec), nil
+	}
+}
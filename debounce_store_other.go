@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// lockFile はファイルロックに対応していない環境では何もしない
+// プロセス内の排他のみとなり、複数のプロセスが同時に更新した場合は後の保存が優先される
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile はファイルロックに対応していない環境では何もしない
+func unlockFile(f *os.File) error {
+	return nil
+}
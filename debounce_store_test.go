@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingDebounceStore は常にエラーを返すDebounceStore
+type failingDebounceStore struct{}
+
+func (failingDebounceStore) Update(team string, fn func(entries map[string]debounceEntry) error) error {
+	return errors.New("保存できません")
+}
+
+func TestFileDebounceStore(t *testing.T) {
+	t.Run("再起動後も履歴が残る", func(t *testing.T) {
+		resetDebounce()
+		defer resetDebounce()
+		path := filepath.Join(t.TempDir(), "times-esa", "debounce.json")
+
+		SetDebounceStore(newFileDebounceStore(path))
+		assert.False(t, isDebouncedForTeam("work", "再起動前の投稿"))
+
+		// 新しいストアを作り直しても（プロセスの再起動）、同じファイルの履歴でデバウンスされる
+		SetDebounceStore(newFileDebounceStore(path))
+		assert.True(t, isDebouncedForTeam("work", "再起動前の投稿"))
+		assert.False(t, isDebouncedForTeam("personal", "再起動前の投稿"))
+	})
+
+	t.Run("複数のストアから同時に更新しても履歴が失われない", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "debounce.json")
+
+		// 別々のプロセスを想定して、同じファイルを指す複数のストアから更新する
+		var wg sync.WaitGroup
+		for i := range 4 {
+			store := newFileDebounceStore(path)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 10 {
+					text := strconv.Itoa(i*100 + j)
+					err := store.Update("work", func(entries map[string]debounceEntry) error {
+						entries[text] = debounceEntry{Text: text, Timestamp: time.Now()}
+						return nil
+					})
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		var count int
+		err := newFileDebounceStore(path).Update("work", func(entries map[string]debounceEntry) error {
+			count = len(entries)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 40, count)
+	})
+
+	t.Run("壊れたファイルは空の履歴として扱う", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "debounce.json")
+		require.NoError(t, os.WriteFile(path, []byte("{broken"), 0o600))
+
+		var count int
+		err := newFileDebounceStore(path).Update("work", func(entries map[string]debounceEntry) error {
+			count = len(entries)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("保存できない場合はプロセス内の履歴で判定する", func(t *testing.T) {
+		resetDebounce()
+		defer resetDebounce()
+
+		SetDebounceStore(failingDebounceStore{})
+		assert.False(t, isDebouncedForTeam("work", "保存できない投稿"))
+		assert.True(t, isDebouncedForTeam("work", "保存できない投稿"))
+	})
+}
+
+func TestNewDebounceStore(t *testing.T) {
+	store, err := NewDebounceStore("memory")
+	require.NoError(t, err)
+	assert.IsType(t, &memoryDebounceStore{}, store)
+
+	store, err = NewDebounceStore("/tmp/times-esa/debounce.json")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/times-esa/debounce.json", store.(*fileDebounceStore).path)
+
+	// 空ならユーザーのキャッシュディレクトリ
+	t.Setenv("XDG_CACHE_HOME", "/tmp/cache")
+	t.Setenv("HOME", "/tmp/home")
+	dir, err := os.UserCacheDir()
+	require.NoError(t, err)
+	store, err = NewDebounceStore("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "times-esa", "debounce.json"), store.(*fileDebounceStore).path)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ToolError はツールの呼び出しが失敗した理由を、クライアントが機械的に判別できる形で表すエラー
+// 同じCodeのToolErrorはerrors.Isで同じ種類のエラーとみなす（errors.Is(err, ErrDebounced)など）
+type ToolError struct {
+	Code       string        // エラーの種類（not_confirmed・debouncedなど）
+	Message    string        // 利用者向けのメッセージ
+	HTTPStatus int           // esa.io APIが返したHTTPステータス（APIのエラーでなければ0）
+	Retryable  bool          // 同じ呼び出しを後でやり直せば成功する可能性があるかどうか
+	RetryAfter time.Duration // やり直すまでに待つべき時間（わからなければ0）
+	Err        error         // 原因となったエラー
+}
+
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Is はCodeが同じToolErrorを同じ種類のエラーとみなす
+func (e *ToolError) Is(target error) bool {
+	t, ok := target.(*ToolError)
+	return ok && t.Code == e.Code
+}
+
+// エラーの種類を表すToolError（errors.Isで判別するために使う）
+var (
+	// パラメーターが不正
+	ErrInvalidParams = &ToolError{Code: "invalid_params", Message: "パラメーターが不正です"}
+	// ユーザーによる確認（confirmed_by_user=true）がない
+	ErrNotConfirmed = &ToolError{Code: "not_confirmed", Message: "ユーザーによる内容の確認が必要です"}
+	// 短時間に同じ内容の投稿が行われた
+	ErrDebounced = &ToolError{Code: "debounced", Message: "同じ内容の投稿が行われました", Retryable: true}
+	// 同じ日の日報が複数存在する
+	ErrMultipleDailyReports = &ToolError{Code: "multiple_daily_reports", Message: "複数の日報が存在します"}
+	// 対象の日報やエントリが存在しない
+	ErrNotFound = &ToolError{Code: "not_found", Message: "対象が見つかりません"}
+	// 他の編集との競合が解消できなかった
+	ErrConflict = &ToolError{Code: "conflict", Message: "他の編集と競合しました", Retryable: true}
+	// 他の編集とesa.ioで自動マージできず、コンフリクトマーカー付きで保存された（保存済みのため再試行しない）
+	ErrOverlapped = &ToolError{Code: "overlapped", Message: "他の編集と競合したため、コンフリクトマーカー付きで保存されました"}
+	// チーム名やアクセストークンが設定されていない
+	ErrNotConfigured = &ToolError{Code: "not_configured", Message: "チーム名またはアクセストークンが設定されていません"}
+	// アクセストークンが無効
+	ErrUnauthorized = &ToolError{Code: "unauthorized", Message: "esa.io APIの認証に失敗しました", HTTPStatus: http.StatusUnauthorized}
+	// アクセストークンの権限が足りない
+	ErrForbidden = &ToolError{Code: "forbidden", Message: "esa.io APIの権限がありません", HTTPStatus: http.StatusForbidden}
+	// esa.io APIのレート制限を超えた
+	ErrRateLimited = &ToolError{Code: "rate_limited", Message: "esa.io APIのレート制限を超えました", HTTPStatus: http.StatusTooManyRequests, Retryable: true}
+	// esa.io APIが一時的に利用できない（5xx）
+	ErrEsaUnavailable = &ToolError{Code: "esa_unavailable", Message: "esa.io APIが一時的に利用できません", HTTPStatus: http.StatusServiceUnavailable, Retryable: true}
+	// その他のesa.io APIのエラー
+	ErrEsaAPI = &ToolError{Code: "esa_api_error", Message: "esa.io APIがエラーを返しました"}
+	// esa.io APIに接続できない、または応答がない
+	ErrNetwork = &ToolError{Code: "network_error", Message: "esa.io APIに接続できませんでした", Retryable: true}
+)
+
+// unknownErrorCode は種類のわからないエラーのコード
+const unknownErrorCode = "unknown_error"
+
+// newToolError はkindと同じ種類のToolErrorを作る
+// メッセージはfmt.Errorfと同じ形式で指定し、%wで渡したエラーを原因として保持する
+func newToolError(kind *ToolError, format string, args ...any) *ToolError {
+	err := fmt.Errorf(format, args...)
+	return &ToolError{
+		Code:       kind.Code,
+		Message:    err.Error(),
+		HTTPStatus: kind.HTTPStatus,
+		Retryable:  kind.Retryable,
+		RetryAfter: kind.RetryAfter,
+		Err:        errors.Unwrap(err),
+	}
+}
+
+// newEsaAPIError はesa.io APIのエラーレスポンスからToolErrorを作る
+// HTTPステータスからエラーの種類を決め、レート制限の場合は解除までの時間を添える
+func newEsaAPIError(resp *http.Response, now time.Time) *ToolError {
+	kind := ErrEsaAPI
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		kind = ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		kind = ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case resp.StatusCode >= http.StatusInternalServerError:
+		kind = ErrEsaUnavailable
+	}
+
+	var toolErr *ToolError
+	var errorResp EsaErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+		toolErr = newToolError(kind, "esa.io APIがHTTP %dを返しました（エラーレスポンスの解析に失敗: %w）", resp.StatusCode, err)
+	} else {
+		toolErr = newToolError(kind, "%s: %s", errorResp.Error, errorResp.Message)
+	}
+	toolErr.HTTPStatus = resp.StatusCode
+	if wait, ok := rateLimitWait(resp.Header, now); ok && kind.Retryable {
+		toolErr.RetryAfter = wait
+	}
+	return toolErr
+}
+
+// toolErrorFor はerrの原因からToolErrorを探す
+// ToolErrorでないエラーは、ネットワークのエラーなら種類を補い、それ以外は種類のわからないエラーとして扱う
+// キャンセルされたリクエストのエラーもnet.Errorを実装するため、ネットワークのエラーとはみなさない
+func toolErrorFor(err error) *ToolError {
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return toolErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return &ToolError{Code: unknownErrorCode, Message: err.Error(), Err: err}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return newToolError(ErrNetwork, "%w", err)
+	}
+	return &ToolError{Code: unknownErrorCode, Message: err.Error(), Err: err}
+}
+
+// newToolErrorResponse はツールの呼び出しが失敗した理由を表すレスポンスを返す
+func newToolErrorResponse(err error) ToolErrorResponse {
+	toolErr := toolErrorFor(err)
+	return ToolErrorResponse{
+		Success: false,
+		Error: ToolErrorDetail{
+			Code:              toolErr.Code,
+			Message:           err.Error(),
+			HTTPStatus:        toolErr.HTTPStatus,
+			Retryable:         toolErr.Retryable,
+			RetryAfterSeconds: int(toolErr.RetryAfter.Round(time.Second).Seconds()),
+		},
+	}
+}
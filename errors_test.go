@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEsaAPIError(t *testing.T) {
+	now := time.Date(2025, 5, 3, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		statusCode         int
+		headers            map[string]string
+		body               string
+		expectedKind       *ToolError
+		expectedMessage    string
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name:            "無効なアクセストークン",
+			statusCode:      http.StatusUnauthorized,
+			body:            `{"error": "unauthorized", "message": "Invalid access token"}`,
+			expectedKind:    ErrUnauthorized,
+			expectedMessage: "unauthorized: Invalid access token",
+		},
+		{
+			name:            "権限がない",
+			statusCode:      http.StatusForbidden,
+			body:            `{"error": "forbidden", "message": "Forbidden"}`,
+			expectedKind:    ErrForbidden,
+			expectedMessage: "forbidden: Forbidden",
+		},
+		{
+			name:            "投稿が存在しない",
+			statusCode:      http.StatusNotFound,
+			body:            `{"error": "not_found", "message": "Not found"}`,
+			expectedKind:    ErrNotFound,
+			expectedMessage: "not_found: Not found",
+		},
+		{
+			name:               "レート制限",
+			statusCode:         http.StatusTooManyRequests,
+			headers:            map[string]string{"X-RateLimit-Reset": fmt.Sprint(now.Add(90 * time.Second).Unix())},
+			body:               `{"error": "too_many_requests", "message": "Rate limit exceeded"}`,
+			expectedKind:       ErrRateLimited,
+			expectedMessage:    "too_many_requests: Rate limit exceeded",
+			expectedRetryAfter: 90 * time.Second,
+		},
+		{
+			name:               "サーバーエラー",
+			statusCode:         http.StatusServiceUnavailable,
+			headers:            map[string]string{"Retry-After": "30"},
+			body:               `<html>maintenance</html>`,
+			expectedKind:       ErrEsaUnavailable,
+			expectedMessage:    "esa.io APIがHTTP 503を返しました（エラーレスポンスの解析に失敗",
+			expectedRetryAfter: 30 * time.Second,
+		},
+		{
+			name:            "その他のエラー",
+			statusCode:      http.StatusBadRequest,
+			body:            `{"error": "bad_request", "message": "Invalid params"}`,
+			expectedKind:    ErrEsaAPI,
+			expectedMessage: "bad_request: Invalid params",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := statusResponse(tt.statusCode, tt.headers)
+			resp.Body = io.NopCloser(strings.NewReader(tt.body))
+
+			err := newEsaAPIError(resp, now)
+			assert.ErrorIs(t, err, tt.expectedKind)
+			assert.Contains(t, err.Error(), tt.expectedMessage)
+			assert.Equal(t, tt.statusCode, err.HTTPStatus)
+			assert.Equal(t, tt.expectedKind.Retryable, err.Retryable)
+			assert.Equal(t, tt.expectedRetryAfter, err.RetryAfter)
+		})
+	}
+}
+
+func TestNewToolErrorResponse(t *testing.T) {
+	t.Run("ラップされたToolErrorの種類を返す", func(t *testing.T) {
+		cause := newToolError(ErrRateLimited, "too_many_requests: Rate limit exceeded")
+		cause.HTTPStatus = http.StatusTooManyRequests
+		cause.RetryAfter = 90 * time.Second
+		err := fmt.Errorf("投稿の検索に失敗しました: %w", cause)
+
+		assert.Equal(t, ToolErrorResponse{
+			Success: false,
+			Error: ToolErrorDetail{
+				Code:              "rate_limited",
+				Message:           "投稿の検索に失敗しました: too_many_requests: Rate limit exceeded",
+				HTTPStatus:        http.StatusTooManyRequests,
+				Retryable:         true,
+				RetryAfterSeconds: 90,
+			},
+		}, newToolErrorResponse(err))
+	})
+
+	t.Run("タイムアウトはネットワークのエラー", func(t *testing.T) {
+		err := fmt.Errorf("投稿の検索に失敗しました: %w", context.DeadlineExceeded)
+
+		detail := newToolErrorResponse(err).Error
+		assert.Equal(t, "network_error", detail.Code)
+		assert.True(t, detail.Retryable)
+	})
+
+	t.Run("キャンセルされたリクエストはネットワークのエラーではない", func(t *testing.T) {
+		err := fmt.Errorf("投稿の検索に失敗しました: %w", &url.Error{Op: "Get", URL: "https://api.esa.io/v1", Err: context.Canceled})
+
+		detail := newToolErrorResponse(err).Error
+		assert.Equal(t, unknownErrorCode, detail.Code)
+		assert.False(t, detail.Retryable)
+	})
+
+	t.Run("種類のわからないエラー", func(t *testing.T) {
+		detail := newToolErrorResponse(fmt.Errorf("予期しないエラー")).Error
+		assert.Equal(t, unknownErrorCode, detail.Code)
+		assert.Equal(t, "予期しないエラー", detail.Message)
+		assert.False(t, detail.Retryable)
+	})
+
+	t.Run("コードが同じToolErrorはerrors.Isで判別できる", func(t *testing.T) {
+		err := fmt.Errorf("wrap: %w", newToolError(ErrNotConfirmed, "確認してください"))
+		assert.ErrorIs(t, err, ErrNotConfirmed)
+		assert.NotErrorIs(t, err, ErrDebounced)
+	})
+}
+
+func TestSubmitDailyReportHandler_ErrorResult(t *testing.T) {
+	t.Run("設定が不足している場合", func(t *testing.T) {
+		factory := &DefaultHandlerFactory{Config: DefaultConfig()}
+
+		result, out, err := factory.submitDailyReportHandler(context.Background(), nil, TimesEsaPostRequest{Text: "テスト", ConfirmedByUser: true})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "ESA_TEAM_NAME")
+
+		// structured contentとしてエラーの種類を返す
+		data, err := json.Marshal(out)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"success": false,
+			"error": {
+				"code": "not_configured",
+				"message": "ESA_TEAM_NAME または ESA_ACCESS_TOKEN が設定されていません",
+				"retryable": false
+			}
+		}`, string(data))
+	})
+
+	t.Run("MCPのクライアントからエラーの種類を判別できる", func(t *testing.T) {
+		resetDebounce()
+		config := DefaultConfig()
+		config.TeamName = "test-team"
+		config.AccessToken = "test-token"
+		factory := &DefaultHandlerFactory{Config: config}
+
+		server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0.0"}, nil)
+		mcp.AddTool(server, &mcp.Tool{Name: "times-esa"}, factory.submitDailyReportHandler)
+
+		clientTransport, serverTransport := mcp.NewInMemoryTransports()
+		ctx := context.Background()
+		serverSession, err := server.Connect(ctx, serverTransport, nil)
+		require.NoError(t, err)
+		defer serverSession.Close()
+
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+		session, err := client.Connect(ctx, clientTransport, nil)
+		require.NoError(t, err)
+		defer session.Close()
+
+		result, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "times-esa",
+			Arguments: map[string]any{"text": "テスト", "confirmed_by_user": false},
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		data, err := json.Marshal(result.StructuredContent)
+		require.NoError(t, err)
+		var response ToolErrorResponse
+		require.NoError(t, json.Unmarshal(data, &response))
+		assert.Equal(t, "not_confirmed", response.Error.Code)
+		assert.False(t, response.Error.Retryable)
+	})
+}
+
+// esa.io APIのエラーがハンドラーの戻り値まで種類を保ったまま伝わることのテスト
+func TestEsaClient_TypedErrors(t *testing.T) {
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(`{"error": "unauthorized", "message": "Invalid access token"}`)),
+	}, nil)
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{TeamName: "test-team", AccessToken: "invalid"})
+	_, err := client.SearchPostByCategory(context.Background(), "日報/2025/05/03")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	detail := newToolErrorResponse(fmt.Errorf("投稿の検索に失敗しました: %w", err)).Error
+	assert.Equal(t, "unauthorized", detail.Code)
+	assert.Equal(t, http.StatusUnauthorized, detail.HTTPStatus)
+}
@@ -2,13 +2,14 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,8 +20,49 @@ const (
 	// エンドポイントのパス定義
 	esaPostsEndpoint = "/teams/%s/posts"    // チームの投稿一覧
 	esaPostEndpoint  = "/teams/%s/posts/%d" // 特定の投稿
+	esaUserEndpoint  = "/user"              // 認証中のユーザー
+
+	// maxUpdateAttempts は投稿の更新が競合した場合に追記を試みる最大回数
+	maxUpdateAttempts = 3
+
+	// maxDailyReportsPerCategory は同じカテゴリーの日報を取得する最大件数（APIの1ページの上限）
+	maxDailyReportsPerCategory = 100
+
+	// archivedCategoryPrefix はアーカイブした投稿を移動するカテゴリー（esa.ioのArchived）
+	archivedCategoryPrefix = "Archived/"
+)
+
+// 同じ日の日報が複数存在する場合の扱い（EsaConfig.DuplicatePolicyで指定する）
+const (
+	// 最も古い日報に追記する（デフォルト）
+	duplicatePolicyOldest = "oldest"
+	// 最も新しい日報に追記する
+	duplicatePolicyNewest = "newest"
+	// 最も古い日報に他の日報の本文をまとめ、他の日報はアーカイブする
+	duplicatePolicyMerge = "merge"
+	// エラーにする
+	duplicatePolicyError = "error"
 )
 
+// duplicatePolicies は指定できる重複した日報の扱い
+var duplicatePolicies = []string{duplicatePolicyOldest, duplicatePolicyNewest, duplicatePolicyMerge, duplicatePolicyError}
+
+// 日報にエントリを書く位置（EsaConfig.EntryPlacementで指定する）
+const (
+	// 先頭に書く（新しい順、デフォルト）
+	entryPlacementPrepend = "prepend"
+	// 末尾に書く（古い順）
+	entryPlacementAppend = "append"
+	// 新しい順に並んだエントリの中の時刻順の位置に書く
+	entryPlacementInsertByTime = "insert_by_time"
+)
+
+// entryPlacements は指定できるエントリを書く位置
+var entryPlacements = []string{entryPlacementPrepend, entryPlacementAppend, entryPlacementInsertByTime}
+
+// errRevisionConflict は更新中に他の編集で投稿が変更されていたことを表す
+var errRevisionConflict = errors.New("投稿が他の編集によって更新されています")
+
 // searchConfig は検索オプションを保持する構造体
 type searchConfig struct {
 	query         string
@@ -36,10 +78,17 @@ type SearchOption func(*searchConfig)
 
 // EsaClientInterface はesa.ioとの通信を担当するインターフェース
 type EsaClientInterface interface {
-	Search(options ...SearchOption) (*EsaSearchResult, error)
-	SearchPostByCategory(category string) (*EsaPost, error)
-	CreatePost(text string) (*EsaPost, error)
-	UpdatePost(existingPost *EsaPost, text string) (*EsaPost, error)
+	Search(ctx context.Context, options ...SearchOption) (*EsaSearchResult, error)
+	SearchPostByCategory(ctx context.Context, category string) (*EsaPost, error)
+	ResolveDailyReport(ctx context.Context, category string) (*EsaPost, error)
+	SearchPostsByCategory(ctx context.Context, category string) ([]EsaPost, error)
+	DailyReportCategory(ctx context.Context, now time.Time) (string, error)
+	GetPost(ctx context.Context, number int) (*EsaPost, error)
+	CreatePost(ctx context.Context, text, section string, now time.Time) (*EsaPost, error)
+	UpdatePost(ctx context.Context, existingPost *EsaPost, text, section string, now time.Time) (*EsaPost, error)
+	InsertEntry(ctx context.Context, existingPost *EsaPost, text, section string, at time.Time) (*EsaPost, error)
+	EditPost(ctx context.Context, existingPost *EsaPost, edit func(bodyMd string) (string, error)) (*EsaPost, error)
+	MergePosts(ctx context.Context, primary *EsaPost, duplicates []EsaPost) (*EsaPost, error)
 }
 
 // HTTPClientInterface はHTTPクライアントの操作をモック可能にするインターフェース
@@ -69,6 +118,10 @@ func NewHTTPClient(timeout time.Duration) HTTPClientInterface {
 type EsaClient struct {
 	httpClient HTTPClientInterface
 	config     EsaConfig
+
+	// APIから取得したscreen_nameのキャッシュ
+	screenNameMu sync.Mutex
+	screenName   string
 }
 
 // NewEsaClient は新しいEsaClientを作成する
@@ -79,42 +132,277 @@ func NewEsaClient(httpClient HTTPClientInterface, config EsaConfig) *EsaClient {
 	}
 }
 
-// ConfigFromEnv は環境変数からEsaConfigを生成する
-func ConfigFromEnv() EsaConfig {
-	teamName := os.Getenv("ESA_TEAM_NAME")
-	accessToken := os.Getenv("ESA_ACCESS_TOKEN")
-	return EsaConfig{
-		TeamName:    teamName,
-		AccessToken: accessToken,
+// RateLimit はHTTPクライアントが記録した直近のレート制限の状況を返す
+func (c *EsaClient) RateLimit() (RateLimit, bool) {
+	if reporter, ok := c.httpClient.(RateLimitReporter); ok {
+		return reporter.RateLimit()
+	}
+	return RateLimit{}, false
+}
+
+// DayBoundary は設定された日報の1日の区切りを返す
+func (c *EsaClient) DayBoundary() DayBoundary {
+	return c.config.dayBoundary()
+}
+
+// DailyReportCategory は指定した日時の日報のカテゴリーをテンプレートから求める
+func (c *EsaClient) DailyReportCategory(ctx context.Context, now time.Time) (string, error) {
+	data, err := c.templateData(ctx, now, c.config.categoryTemplate())
+	if err != nil {
+		return "", err
+	}
+	category, err := renderDailyReportTemplate("カテゴリー", c.config.categoryTemplate(), data)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(category, "/"), nil
+}
+
+// dailyReportTitle は指定した日時の日報のタイトルをテンプレートから求める
+func (c *EsaClient) dailyReportTitle(ctx context.Context, now time.Time) (string, error) {
+	data, err := c.templateData(ctx, now, c.config.titleTemplate())
+	if err != nil {
+		return "", err
+	}
+	return renderDailyReportTemplate("タイトル", c.config.titleTemplate(), data)
+}
+
+// dailyReportBody は指定した日時の日報を作成するときの本文をテンプレートから求める（テンプレートが未設定なら空）
+// esa.ioのテンプレートの投稿が設定されている場合は、その投稿を取得してesa.ioの記法を展開する
+func (c *EsaClient) dailyReportBody(ctx context.Context, now time.Time) (string, error) {
+	if c.config.TemplatePostID != 0 || c.config.TemplateCategory != "" {
+		template, err := c.templatePost(ctx)
+		if err != nil {
+			return "", fmt.Errorf("テンプレートの投稿の取得に失敗: %w", err)
+		}
+		data, err := c.templateData(ctx, now, template.BodyMd)
+		if err != nil {
+			return "", err
+		}
+		return expandEsaTemplate(template.BodyMd, data), nil
+	}
+
+	if c.config.BodyTemplate == "" {
+		return "", nil
+	}
+	data, err := c.templateData(ctx, now, c.config.BodyTemplate)
+	if err != nil {
+		return "", err
+	}
+	return renderDailyReportTemplate("本文", c.config.BodyTemplate, data)
+}
+
+// templatePost は設定されたesa.ioのテンプレートの投稿を取得する
+// カテゴリーで指定した場合は、そのカテゴリーの最も古い（投稿番号の小さい）投稿を使う
+func (c *EsaClient) templatePost(ctx context.Context) (*EsaPost, error) {
+	if c.config.TemplatePostID != 0 {
+		return c.GetPost(ctx, c.config.TemplatePostID)
+	}
+
+	posts, err := c.SearchPostsByCategory(ctx, c.config.TemplateCategory)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, newToolError(ErrNotFound, "カテゴリー%sに投稿がありません", c.config.TemplateCategory)
+	}
+	return &posts[0], nil
+}
+
+// templateData はテンプレートに渡す値を作る
+// テンプレートがscreen_nameを参照していて設定もされていない場合のみ、APIから取得する
+func (c *EsaClient) templateData(ctx context.Context, now time.Time, text string) (DailyReportTemplateData, error) {
+	screenName := c.config.ScreenName
+	if screenName == "" && usesScreenName(text) {
+		var err error
+		screenName, err = c.currentScreenName(ctx)
+		if err != nil {
+			return DailyReportTemplateData{}, fmt.Errorf("screen_nameの取得に失敗: %w", err)
+		}
+	}
+	// 日付が変わる時刻より前なら前日の日報になる
+	return NewDailyReportTemplateData(c.config.dayBoundary().ReportDay(now), screenName), nil
+}
+
+// currentScreenName は認証中のユーザーのscreen_nameをAPIから取得する
+func (c *EsaClient) currentScreenName(ctx context.Context) (string, error) {
+	c.screenNameMu.Lock()
+	defer c.screenNameMu.Unlock()
+
+	if c.screenName != "" {
+		return c.screenName, nil
+	}
+
+	// リクエストの作成
+	req, err := http.NewRequestWithContext(ctx, "GET", esaAPIBaseURL+esaUserEndpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Authorization", "Bearer "+c.config.AccessToken)
+
+	// リクエストの実行
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// レスポンスの解析
+	if resp.StatusCode != http.StatusOK {
+		return "", newEsaAPIError(resp, time.Now())
+	}
+
+	var user EsaUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", fmt.Errorf("ユーザー情報の解析に失敗: %w", err)
 	}
+
+	c.screenName = user.ScreenName
+	return c.screenName, nil
 }
 
 // SearchPostByCategory はカテゴリから投稿を検索する
-func (c *EsaClient) SearchPostByCategory(category string) (*EsaPost, error) {
-	// Searchメソッドを使用
-	result, err := c.Search(
-		WithCategory(category),
-		WithPagination(1, 1),
-	)
+// 同じカテゴリーの日報が複数存在する場合は、設定された扱い（DuplicatePolicy）に従って1件に決める
+// 読み取りにも使うため投稿は書き換えず、mergeの場合もまとめる先の最も古い日報を返す
+func (c *EsaClient) SearchPostByCategory(ctx context.Context, category string) (*EsaPost, error) {
+	posts, err := c.SearchPostsByCategory(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	return c.selectDailyReport(category, posts)
+}
+
+// ResolveDailyReport は日報に投稿するときの投稿先を検索する
+// SearchPostByCategoryと同じく1件に決めるが、mergeの場合は重複した日報を最も古い日報にまとめてから返す
+func (c *EsaClient) ResolveDailyReport(ctx context.Context, category string) (*EsaPost, error) {
+	posts, err := c.SearchPostsByCategory(ctx, category)
 	if err != nil {
 		return nil, err
 	}
+	if len(posts) > 1 && c.config.DuplicatePolicy == duplicatePolicyMerge {
+		return c.MergePosts(ctx, &posts[0], posts[1:])
+	}
+	return c.selectDailyReport(category, posts)
+}
 
-	// 検索結果の処理
-	if result.TotalCount == 0 {
+// selectDailyReport は同じカテゴリーの日報postsから、設定された扱いに従って1件を選ぶ（投稿がなければnil）
+func (c *EsaClient) selectDailyReport(category string, posts []EsaPost) (*EsaPost, error) {
+	switch {
+	case len(posts) == 0:
 		// 投稿が存在しない
 		return nil, nil
-	} else if result.TotalCount > 1 {
-		// 複数の投稿が存在する
-		return nil, errors.New("複数の日報が存在します")
+	case len(posts) == 1:
+		return &posts[0], nil
+	}
+
+	// 複数の投稿が存在する
+	switch c.config.DuplicatePolicy {
+	case duplicatePolicyNewest:
+		return &posts[len(posts)-1], nil
+	case duplicatePolicyError:
+		return nil, newToolError(ErrMultipleDailyReports, "複数の日報が存在します（%s: %s）。times-esa-duplicatesで1件にまとめてください", category, postNumbers(posts))
+	default:
+		// oldest・mergeは最も古い日報（mergeの場合はまとめる先）
+		return &posts[0], nil
+	}
+}
+
+// SearchPostsByCategory はカテゴリーが完全に一致する投稿を古い順（投稿番号の昇順）に返す
+func (c *EsaClient) SearchPostsByCategory(ctx context.Context, category string) ([]EsaPost, error) {
+	result, err := c.Search(ctx,
+		WithCategoryExact(category),
+		WithSort("number", "asc"),
+		WithPagination(1, maxDailyReportsPerCategory),
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	// 最新の投稿を返す
-	return &result.Posts[0], nil
+	// 念のため、カテゴリーが完全に一致しない投稿は除く
+	posts := make([]EsaPost, 0, len(result.Posts))
+	for _, post := range result.Posts {
+		if post.Category == category {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// MergePosts は重複した日報duplicatesの本文をprimaryの末尾にまとめ、duplicatesをアーカイブする
+// まとめた後にアーカイブするため、途中で失敗しても本文が失われることはない
+func (c *EsaClient) MergePosts(ctx context.Context, primary *EsaPost, duplicates []EsaPost) (*EsaPost, error) {
+	bodies := make([]string, 0, len(duplicates))
+	for _, post := range duplicates {
+		bodies = append(bodies, post.BodyMd)
+	}
+
+	merged, err := c.EditPost(ctx, primary, func(bodyMd string) (string, error) {
+		return mergeDailyReportBodies(bodyMd, bodies), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("日報#%dへの統合に失敗: %w", primary.Number, err)
+	}
+
+	for _, post := range duplicates {
+		if err := c.archivePost(ctx, &post); err != nil {
+			return nil, fmt.Errorf("日報#%dのアーカイブに失敗: %w", post.Number, err)
+		}
+	}
+	return merged, nil
+}
+
+// archivePost は投稿をArchived/以下のカテゴリーに移動する
+func (c *EsaClient) archivePost(ctx context.Context, post *EsaPost) error {
+	url := fmt.Sprintf("%s"+esaPostEndpoint, esaAPIBaseURL, c.config.TeamName, post.Number)
+
+	// リクエストボディの作成
+	type archiveRequest struct {
+		Post struct {
+			Category string `json:"category"`
+		} `json:"post"`
+	}
+
+	reqBody := archiveRequest{}
+	reqBody.Post.Category = archivedCategoryPrefix + post.Category
+
+	// JSONに変換
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("リクエストのJSON変換に失敗: %w", err)
+	}
+
+	// リクエストの作成
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+c.config.AccessToken)
+
+	// リクエストの実行
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newEsaAPIError(resp, time.Now())
+	}
+	return nil
+}
+
+// postNumbers は投稿番号を「#1, #2」の形式で返す
+func postNumbers(posts []EsaPost) string {
+	numbers := make([]string, 0, len(posts))
+	for _, post := range posts {
+		numbers = append(numbers, fmt.Sprintf("#%d", post.Number))
+	}
+	return strings.Join(numbers, ", ")
 }
 
 // Search は汎用的な検索を実行する
-func (c *EsaClient) Search(options ...SearchOption) (*EsaSearchResult, error) {
+func (c *EsaClient) Search(ctx context.Context, options ...SearchOption) (*EsaSearchResult, error) {
 	// デフォルト設定
 	config := &searchConfig{
 		page:    1,
@@ -168,7 +456,7 @@ func (c *EsaClient) Search(options ...SearchOption) (*EsaSearchResult, error) {
 	}
 
 	// リクエストの作成
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -183,11 +471,7 @@ func (c *EsaClient) Search(options ...SearchOption) (*EsaSearchResult, error) {
 
 	// レスポンスの解析
 	if resp.StatusCode != http.StatusOK {
-		var errorResp EsaErrorResponse
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
-			return nil, fmt.Errorf("エラーレスポンスの解析に失敗: %w", err)
-		}
-		return nil, fmt.Errorf("%s: %s", errorResp.Error, errorResp.Message)
+		return nil, newEsaAPIError(resp, time.Now())
 	}
 
 	var searchResult EsaSearchResult
@@ -199,12 +483,23 @@ func (c *EsaClient) Search(options ...SearchOption) (*EsaSearchResult, error) {
 }
 
 // CreatePost は新しい投稿を作成する
-func (c *EsaClient) CreatePost(text string) (*EsaPost, error) {
-	// デフォルト値の設定
-	now := time.Now()
-	category := fmt.Sprintf("日報/%04d/%02d/%02d", now.Year(), now.Month(), now.Day())
-	title := "日報"
-	var tags []string
+// カテゴリーとタイムスタンプは呼び出し元が指定した時刻nowから求める
+// 本文のテンプレートが設定されている場合は、テンプレートを展開した本文の見出しsectionの下（空なら設定した位置）に最初のエントリを書く
+func (c *EsaClient) CreatePost(ctx context.Context, text, section string, now time.Time) (*EsaPost, error) {
+	// 設定されたテンプレートからカテゴリーとタイトルを求める
+	category, err := c.DailyReportCategory(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	title, err := c.dailyReportTitle(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.dailyReportBody(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	tags := c.config.Tags
 
 	url := fmt.Sprintf("%s"+esaPostsEndpoint, esaAPIBaseURL, c.config.TeamName)
 
@@ -224,9 +519,10 @@ func (c *EsaClient) CreatePost(text string) (*EsaPost, error) {
 	reqBody.Post.Category = category
 	reqBody.Post.Tags = tags
 
-	// 現在時刻をアンカーリンク付きで取得し、テキストの前に追加、その後に区切り線を追加
-	timePrefix := GenerateTimestampWithAnchor(now)
-	reqBody.Post.BodyMd = fmt.Sprintf("%s %s\n\n---", timePrefix, text)
+	// 投稿時刻をアンカーリンク付きで取得し、テキストの前に追加、その後に区切り線を追加
+	boundary := c.config.dayBoundary()
+	timePrefix := GenerateTimestampWithAnchor(boundary.In(now))
+	reqBody.Post.BodyMd, _ = PlaceDailyReportEntryInSection(body, fmt.Sprintf("%s %s", timePrefix, text), section, c.config.EntryPlacement, boundary.In(now), boundary.RolloverHour)
 
 	reqBody.Post.Wip = false
 
@@ -237,7 +533,7 @@ func (c *EsaClient) CreatePost(text string) (*EsaPost, error) {
 	}
 
 	// リクエストの作成
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
@@ -247,17 +543,13 @@ func (c *EsaClient) CreatePost(text string) (*EsaPost, error) {
 	// リクエストの実行
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, writeFailure(err)
 	}
 	defer resp.Body.Close()
 
 	// レスポンスの解析
 	if resp.StatusCode != http.StatusCreated {
-		var errorResp EsaErrorResponse
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
-			return nil, fmt.Errorf("エラーレスポンスの解析に失敗: %w", err)
-		}
-		return nil, fmt.Errorf("%s: %s", errorResp.Error, errorResp.Message)
+		return nil, writeFailure(newEsaAPIError(resp, time.Now()))
 	}
 
 	var post EsaPost
@@ -269,31 +561,105 @@ func (c *EsaClient) CreatePost(text string) (*EsaPost, error) {
 }
 
 // UpdatePost は既存の投稿を更新する
-func (c *EsaClient) UpdatePost(existingPost *EsaPost, text string) (*EsaPost, error) {
-	url := fmt.Sprintf("%s"+esaPostEndpoint, esaAPIBaseURL, c.config.TeamName, existingPost.Number)
+// タイムスタンプは呼び出し元が指定した時刻nowから求め、見出しsectionの下（空なら本文全体）の設定した位置に追記する
+// 他の編集との競合はEditPostと同じく扱う（esa.ioが競合を返した場合は最新の投稿に追記し直す）
+func (c *EsaClient) UpdatePost(ctx context.Context, existingPost *EsaPost, text, section string, now time.Time) (*EsaPost, error) {
+	boundary := c.config.dayBoundary()
+	now = boundary.In(now)
+	return c.EditPost(ctx, existingPost, func(bodyMd string) (string, error) {
+		if text == "" {
+			return bodyMd, nil
+		}
+		// 投稿時刻をアンカーリンク付きで取得（同じ分のエントリがあってもアンカーIDが重複しないようにする）
+		timePrefix := GenerateUniqueTimestampWithAnchor(now, bodyMd)
+
+		// 区切り線と時刻付きテキストを追記
+		bodyMd, _ = PlaceDailyReportEntryInSection(bodyMd, fmt.Sprintf("%s %s", timePrefix, text), section, c.config.EntryPlacement, now, boundary.RolloverHour)
+		return bodyMd, nil
+	})
+}
+
+// InsertEntry は既存の投稿のエントリの中の時刻順の位置に、時刻atのエントリを挿入する
+// 過去の時刻を指定した投稿や、保留していた投稿を後から書き込む場合に使う
+// エントリを末尾に書く設定の場合は古い順、それ以外は新しい順に並んでいるものとして挿入する
+// sectionを指定した場合は、その見出しの下のエントリの中に挿入する
+func (c *EsaClient) InsertEntry(ctx context.Context, existingPost *EsaPost, text, section string, at time.Time) (*EsaPost, error) {
+	boundary := c.config.dayBoundary()
+	at = boundary.In(at)
+	return c.EditPost(ctx, existingPost, func(bodyMd string) (string, error) {
+		if text == "" {
+			return bodyMd, nil
+		}
+		timePrefix := GenerateUniqueTimestampWithAnchor(at, bodyMd)
+		oldestFirst := c.config.EntryPlacement == entryPlacementAppend
+		bodyMd, _ = editDailyReportSection(bodyMd, section, func(content string) string {
+			return InsertDailyReportEntry(content, fmt.Sprintf("%s %s", timePrefix, text), at, boundary.RolloverHour, oldestFirst)
+		})
+		return bodyMd, nil
+	})
+}
+
+// EditPost は既存の投稿の本文をeditで書き換えて更新する
+// esa.ioが競合を返した場合は、最新の投稿を取得し直してeditを適用し直す（maxUpdateAttempts回まで、超えたらErrConflict）
+// esa.ioが他の編集と自動マージできずにコンフリクトマーカー付きで保存した場合は、保存済みのため再試行せずにErrOverlappedを返す
+func (c *EsaClient) EditPost(ctx context.Context, existingPost *EsaPost, edit func(bodyMd string) (string, error)) (*EsaPost, error) {
+	post := existingPost
+	for attempt := 1; ; attempt++ {
+		bodyMd, err := edit(post.BodyMd)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := c.patchPost(ctx, post, bodyMd)
+		if !errors.Is(err, errRevisionConflict) {
+			return updated, err
+		}
+		if attempt >= maxUpdateAttempts {
+			return nil, newToolError(ErrConflict, "他の編集と%d回競合したため日報を更新できませんでした: %w", attempt, err)
+		}
+
+		// 最新の投稿を取得し直して再試行
+		post, err = c.GetPost(ctx, existingPost.Number)
+		if err != nil {
+			return nil, fmt.Errorf("最新の投稿の取得に失敗: %w", err)
+		}
+	}
+}
+
+// patchPost は投稿の本文を更新する
+// 取得時のリビジョンをoriginal_revisionとして送り、esa.io側で競合を検出させる
+// 他の編集があった場合、esa.ioは自動でマージし、マージできなければコンフリクトマーカー付きで保存してoverlappedを返す
+func (c *EsaClient) patchPost(ctx context.Context, original *EsaPost, bodyMd string) (*EsaPost, error) {
+	url := fmt.Sprintf("%s"+esaPostEndpoint, esaAPIBaseURL, c.config.TeamName, original.Number)
 
 	// リクエストボディの作成
+	type originalRevision struct {
+		BodyMd string `json:"body_md"`
+		Number int    `json:"number"`
+		User   string `json:"user,omitempty"`
+	}
 	type patchRequest struct {
 		Post struct {
-			BodyMd string `json:"body_md"`
-			Wip    bool   `json:"wip"`
+			BodyMd           string            `json:"body_md"`
+			Wip              bool              `json:"wip"`
+			OriginalRevision *originalRevision `json:"original_revision,omitempty"`
 		} `json:"post"`
 	}
 
 	reqBody := patchRequest{}
+	reqBody.Post.BodyMd = bodyMd
+	reqBody.Post.Wip = false
 
-	// テキストを追記（新しいテキストを上に）
-	if text != "" {
-		// 現在時刻をアンカーリンク付きで取得
-		now := time.Now()
-		timePrefix := GenerateTimestampWithAnchor(now)
-
-		// 区切り線と時刻付きテキストを追記
-		reqBody.Post.BodyMd = fmt.Sprintf("%s %s\n\n---\n\n%s", timePrefix, text, existingPost.BodyMd)
-	} else {
-		reqBody.Post.BodyMd = existingPost.BodyMd
+	// リビジョン番号が分かる場合のみ競合検出を有効にする
+	if original.RevisionNumber > 0 {
+		reqBody.Post.OriginalRevision = &originalRevision{
+			BodyMd: original.BodyMd,
+			Number: original.RevisionNumber,
+		}
+		if original.UpdatedBy != nil {
+			reqBody.Post.OriginalRevision.User = original.UpdatedBy.ScreenName
+		}
 	}
-	reqBody.Post.Wip = false
 
 	// JSONに変換
 	jsonData, err := json.Marshal(reqBody)
@@ -302,13 +668,52 @@ func (c *EsaClient) UpdatePost(existingPost *EsaPost, text string) (*EsaPost, er
 	}
 
 	// リクエストの作成
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+c.config.AccessToken)
 
+	// リクエストの実行
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, writeFailure(err)
+	}
+	defer resp.Body.Close()
+
+	// レスポンスの解析
+	if resp.StatusCode == http.StatusConflict {
+		return nil, errRevisionConflict
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, writeFailure(newEsaAPIError(resp, time.Now()))
+	}
+
+	var post EsaPost
+	if err := json.NewDecoder(resp.Body).Decode(&post); err != nil {
+		return nil, fmt.Errorf("投稿の解析に失敗: %w", err)
+	}
+
+	// esa.ioが自動マージできなかった場合は、コンフリクトマーカー付きで保存済みなので再試行しない
+	if post.Overlapped {
+		return nil, newToolError(ErrOverlapped, "日報#%dは他の編集と競合したため、コンフリクトマーカー付きで保存されました。esa上で内容を確認してください", post.Number)
+	}
+
+	return &post, nil
+}
+
+// GetPost は投稿番号を指定して投稿を取得する
+func (c *EsaClient) GetPost(ctx context.Context, number int) (*EsaPost, error) {
+	url := fmt.Sprintf("%s"+esaPostEndpoint, esaAPIBaseURL, c.config.TeamName, number)
+
+	// リクエストの作成
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+c.config.AccessToken)
+
 	// リクエストの実行
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -318,11 +723,7 @@ func (c *EsaClient) UpdatePost(existingPost *EsaPost, text string) (*EsaPost, er
 
 	// レスポンスの解析
 	if resp.StatusCode != http.StatusOK {
-		var errorResp EsaErrorResponse
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
-			return nil, fmt.Errorf("エラーレスポンスの解析に失敗: %w", err)
-		}
-		return nil, fmt.Errorf("%s: %s", errorResp.Error, errorResp.Message)
+		return nil, newEsaAPIError(resp, time.Now())
 	}
 
 	var post EsaPost
@@ -350,21 +751,21 @@ func createHTTPClient() *http.Client {
 func searchPostByCategory(client *http.Client, config EsaConfig, category string) (*EsaPost, error) {
 	httpClient := &standardHTTPClient{client: client}
 	esaClient := NewEsaClient(httpClient, config)
-	return esaClient.SearchPostByCategory(category)
+	return esaClient.SearchPostByCategory(context.Background(), category)
 }
 
 // createPost は新しい投稿を作成する
 func createPost(client *http.Client, config EsaConfig, text string) (*EsaPost, error) {
 	httpClient := &standardHTTPClient{client: client}
 	esaClient := NewEsaClient(httpClient, config)
-	return esaClient.CreatePost(text)
+	return esaClient.CreatePost(context.Background(), text, "", time.Now())
 }
 
 // updatePost は既存の投稿を更新する
 func updatePost(client *http.Client, config EsaConfig, existingPost *EsaPost, text string) (*EsaPost, error) {
 	httpClient := &standardHTTPClient{client: client}
 	esaClient := NewEsaClient(httpClient, config)
-	return esaClient.UpdatePost(existingPost, text)
+	return esaClient.UpdatePost(context.Background(), existingPost, text, "", time.Now())
 }
 
 // WithCategory はカテゴリーの部分一致検索オプションを返す
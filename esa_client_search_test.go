@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // buildTestURL はテスト用のAPIエンドポイントURLを構築する
@@ -132,7 +135,7 @@ func TestSearch_URLConstruction(t *testing.T) {
 			}
 			client := NewEsaClient(mockHTTPClient, config)
 
-			_, err := client.Search(tt.options...)
+			_, err := client.Search(context.Background(), tt.options...)
 			assert.NoError(t, err)
 		})
 	}
@@ -312,7 +315,7 @@ func TestSearchPostByCategory_BackwardCompatibility(t *testing.T) {
 	}
 	client := NewEsaClient(mockHTTPClient, config)
 
-	post, err := client.SearchPostByCategory("日報/2024/12/20")
+	post, err := client.SearchPostByCategory(context.Background(), "日報/2024/12/20")
 	assert.NoError(t, err)
 	assert.NotNil(t, post)
 	assert.Equal(t, 123, post.Number)
@@ -359,7 +362,7 @@ func TestSearch_ErrorHandling(t *testing.T) {
 			}
 			client := NewEsaClient(mockHTTPClient, config)
 
-			_, err := client.Search()
+			_, err := client.Search(context.Background())
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedError)
 		})
@@ -378,7 +381,122 @@ func TestSearch_NetworkError(t *testing.T) {
 	}
 	client := NewEsaClient(mockHTTPClient, config)
 
-	_, err := client.Search()
+	_, err := client.Search(context.Background())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "network error")
 }
+
+// TestSearchPostByCategory_DuplicatePolicy はカテゴリーの完全一致検索と、重複した日報の扱いを検証する
+func TestSearchPostByCategory_DuplicatePolicy(t *testing.T) {
+	const category = "日報/2026/10/1"
+	// 部分一致で別の日（日報/2026/10/16）の日報が混ざった検索結果
+	searchBody := `{
+		"posts": [
+			{"number": 10, "name": "日報", "body_md": "1件目", "category": "日報/2026/10/1"},
+			{"number": 11, "name": "日報", "body_md": "別の日", "category": "日報/2026/10/16"},
+			{"number": 12, "name": "日報", "body_md": "2件目", "category": "日報/2026/10/1"}
+		],
+		"total_count": 3
+	}`
+	searchResponse := func(t *testing.T) func(req *http.Request) (*http.Response, error) {
+		return func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			assert.Equal(t, "on:"+category, query.Get("q"))
+			assert.Equal(t, "number", query.Get("sort"))
+			assert.Equal(t, "asc", query.Get("order"))
+			return jsonResponse(http.StatusOK, searchBody), nil
+		}
+	}
+	newClient := func(httpClient HTTPClientInterface, policy string) *EsaClient {
+		return NewEsaClient(httpClient, EsaConfig{TeamName: "test-team", AccessToken: "test-token", DuplicatePolicy: policy})
+	}
+
+	t.Run("カテゴリーが完全に一致する投稿だけを返す", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		posts, err := newClient(mockHTTPClient, "").SearchPostsByCategory(context.Background(), category)
+		require.NoError(t, err)
+		require.Len(t, posts, 2)
+		assert.Equal(t, 10, posts[0].Number)
+		assert.Equal(t, 12, posts[1].Number)
+	})
+
+	t.Run("デフォルトは最も古い日報", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		post, err := newClient(mockHTTPClient, "").SearchPostByCategory(context.Background(), category)
+		require.NoError(t, err)
+		assert.Equal(t, 10, post.Number)
+	})
+
+	t.Run("newestは最も新しい日報", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		post, err := newClient(mockHTTPClient, duplicatePolicyNewest).SearchPostByCategory(context.Background(), category)
+		require.NoError(t, err)
+		assert.Equal(t, 12, post.Number)
+	})
+
+	t.Run("errorは重複した日報の番号をエラーで返す", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		_, err := newClient(mockHTTPClient, duplicatePolicyError).SearchPostByCategory(context.Background(), category)
+		assert.ErrorIs(t, err, ErrMultipleDailyReports)
+		assert.Contains(t, err.Error(), "#10, #12")
+	})
+
+	t.Run("merge以外は投稿先を決めるときも検索と同じ", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		post, err := newClient(mockHTTPClient, duplicatePolicyNewest).ResolveDailyReport(context.Background(), category)
+		require.NoError(t, err)
+		assert.Equal(t, 12, post.Number)
+	})
+
+	t.Run("mergeでも検索では書き換えずに最も古い日報を返す", func(t *testing.T) {
+		// GET以外のリクエストを送るとモックが失敗する
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		post, err := newClient(mockHTTPClient, duplicatePolicyMerge).SearchPostByCategory(context.Background(), category)
+		require.NoError(t, err)
+		assert.Equal(t, 10, post.Number)
+		assert.Equal(t, "1件目", post.BodyMd)
+	})
+
+	t.Run("mergeは投稿先を決めるときに最も古い日報にまとめて残りをアーカイブする", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(searchResponse(t))
+
+		// 本文をまとめてから、重複した日報をアーカイブする
+		var requests []string
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Post map[string]any `json:"post"`
+			}
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			requests = append(requests, req.URL.Path)
+
+			switch req.URL.Path {
+			case "/v1/teams/test-team/posts/10":
+				assert.Equal(t, "1件目\n\n---\n\n2件目", body.Post["body_md"])
+				return jsonResponse(http.StatusOK, `{"number": 10, "body_md": "1件目\n\n---\n\n2件目", "category": "日報/2026/10/1"}`), nil
+			case "/v1/teams/test-team/posts/12":
+				assert.Equal(t, map[string]any{"category": "Archived/日報/2026/10/1"}, body.Post)
+				return jsonResponse(http.StatusOK, `{"number": 12}`), nil
+			}
+			return nil, fmt.Errorf("予期しないリクエスト: %s", req.URL.Path)
+		}).Times(2)
+
+		post, err := newClient(mockHTTPClient, duplicatePolicyMerge).ResolveDailyReport(context.Background(), category)
+		require.NoError(t, err)
+		assert.Equal(t, 10, post.Number)
+		assert.Equal(t, "1件目\n\n---\n\n2件目", post.BodyMd)
+		assert.Equal(t, []string{"/v1/teams/test-team/posts/10", "/v1/teams/test-team/posts/12"}, requests)
+	})
+}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testContextKey struct{}
+
+// TestEsaClient_PropagatesContext は各メソッドが呼び出し元のcontextをHTTPリクエストに渡すことを検証する
+func TestEsaClient_PropagatesContext(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		call       func(ctx context.Context, client *EsaClient) error
+	}{
+		{
+			name:       "Search",
+			statusCode: http.StatusOK,
+			call: func(ctx context.Context, client *EsaClient) error {
+				_, err := client.Search(ctx)
+				return err
+			},
+		},
+		{
+			name:       "CreatePost",
+			statusCode: http.StatusCreated,
+			call: func(ctx context.Context, client *EsaClient) error {
+				_, err := client.CreatePost(ctx, "テスト", "", time.Now())
+				return err
+			},
+		},
+		{
+			name:       "UpdatePost",
+			statusCode: http.StatusOK,
+			call: func(ctx context.Context, client *EsaClient) error {
+				_, err := client.UpdatePost(ctx, &EsaPost{Number: 1}, "テスト", "", time.Now())
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), testContextKey{}, tt.name)
+
+			mockHTTPClient := NewMockHTTPClientInterface(t)
+			mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+				return req.Context().Value(testContextKey{}) == tt.name
+			})).Return(&http.Response{
+				StatusCode: tt.statusCode,
+				Body:       io.NopCloser(strings.NewReader(`{}`)),
+			}, nil)
+
+			client := NewEsaClient(mockHTTPClient, EsaConfig{
+				TeamName:    "test-team",
+				AccessToken: "test-token",
+			})
+
+			assert.NoError(t, tt.call(ctx, client))
+		})
+	}
+}
+
+// TestEsaClient_ContextCancellation はcontextのキャンセルや期限切れで実行中のリクエストが中断されることを検証する
+func TestEsaClient_ContextCancellation(t *testing.T) {
+	// レスポンスを返さずにクライアントの切断かテスト終了を待つサーバー
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// リクエスト先をテストサーバーに差し替えるHTTPクライアント
+	httpClient := &standardHTTPClient{
+		client: &http.Client{
+			Transport: rewriteHostTransport{target: server.URL},
+			Timeout:   10 * time.Second,
+		},
+	}
+	client := NewEsaClient(httpClient, EsaConfig{
+		TeamName:    "test-team",
+		AccessToken: "test-token",
+	})
+
+	t.Run("期限切れ", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := client.Search(ctx)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("キャンセル", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := client.CreatePost(ctx, "テスト", "", time.Now())
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+// rewriteHostTransport はリクエストのスキームとホストをテストサーバーに書き換える
+type rewriteHostTransport struct {
+	target string
+}
+
+func (t rewriteHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, err := http.NewRequestWithContext(req.Context(), req.Method, t.target+req.URL.RequestURI(), req.Body)
+	if err != nil {
+		return nil, err
+	}
+	target.Header = req.Header
+	return http.DefaultTransport.RoundTrip(target)
+}
+
+// TestEsaClient_UsesGivenTime は日付の境界直前の時刻を渡したとき、その時刻からカテゴリーとアンカーが求められることを検証する
+func TestEsaClient_UsesGivenTime(t *testing.T) {
+	// 日付が変わる直前の時刻
+	beforeMidnight := time.Date(2025, 5, 15, 23, 59, 59, 999_000_000, time.Local)
+
+	t.Run("CreatePost", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			var body struct {
+				Post struct {
+					Category string `json:"category"`
+					BodyMd   string `json:"body_md"`
+				} `json:"post"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return false
+			}
+			assert.Equal(t, "日報/2025/05/15", body.Post.Category)
+			assert.Equal(t, "<a id=\"2359\" href=\"#2359\">23:59</a> テスト\n\n---", body.Post.BodyMd)
+			return true
+		})).Return(&http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+		}, nil)
+
+		client := NewEsaClient(mockHTTPClient, EsaConfig{
+			TeamName:    "test-team",
+			AccessToken: "test-token",
+		})
+
+		_, err := client.CreatePost(context.Background(), "テスト", "", beforeMidnight)
+		assert.NoError(t, err)
+	})
+
+	t.Run("UpdatePost", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			var body struct {
+				Post struct {
+					BodyMd string `json:"body_md"`
+				} `json:"post"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return false
+			}
+			assert.Equal(t, "<a id=\"2359\" href=\"#2359\">23:59</a> テスト\n\n---\n\n既存の内容", body.Post.BodyMd)
+			return true
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+		}, nil)
+
+		client := NewEsaClient(mockHTTPClient, EsaConfig{
+			TeamName:    "test-team",
+			AccessToken: "test-token",
+		})
+
+		_, err := client.UpdatePost(context.Background(), &EsaPost{Number: 1, BodyMd: "既存の内容"}, "テスト", "", beforeMidnight)
+		assert.NoError(t, err)
+	})
+}
+
+func TestEsaClient_DayBoundary(t *testing.T) {
+	// UTCで動いているプロセスでの、日本時間の5/4 01:30
+	now := time.Date(2025, 5, 3, 16, 30, 0, 0, time.UTC)
+
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+		var body struct {
+			Post struct {
+				Name     string `json:"name"`
+				Category string `json:"category"`
+				BodyMd   string `json:"body_md"`
+			} `json:"post"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return false
+		}
+		// 4時より前なので前日の日報に、日本時間の時刻で書く
+		assert.Equal(t, "日報/2025/05/03", body.Post.Category)
+		assert.Equal(t, "日報 2025-05-03", body.Post.Name)
+		assert.Equal(t, "<a id=\"0130\" href=\"#0130\">01:30</a> テスト\n\n---", body.Post.BodyMd)
+		return true
+	})).Return(jsonResponse(http.StatusCreated, `{}`), nil)
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{
+		TeamName:        "test-team",
+		AccessToken:     "test-token",
+		TitleTemplate:   "日報 {{.Date}}",
+		TimeZone:        "Asia/Tokyo",
+		DayRolloverHour: 4,
+	})
+
+	category, err := client.DailyReportCategory(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, "日報/2025/05/03", category)
+
+	_, err = client.CreatePost(context.Background(), "テスト", "", now)
+	assert.NoError(t, err)
+}
+
+func TestUpdatePost_EntryPlacement(t *testing.T) {
+	now := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+	existing := "<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---"
+
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+		var body struct {
+			Post struct {
+				BodyMd string `json:"body_md"`
+			} `json:"post"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return false
+		}
+		// 古い順に読めるよう末尾に追記する
+		assert.Equal(t, existing+"\n\n<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---", body.Post.BodyMd)
+		return true
+	})).Return(jsonResponse(http.StatusOK, `{}`), nil)
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{
+		TeamName:       "test-team",
+		AccessToken:    "test-token",
+		EntryPlacement: entryPlacementAppend,
+	})
+
+	_, err := client.UpdatePost(context.Background(), &EsaPost{Number: 1, BodyMd: existing}, "午後の作業", "", now)
+	assert.NoError(t, err)
+}
+
+// TestCreatePost_BodyTemplate は新しい日報を本文のテンプレートから作り、指定した見出しの下にエントリを書くことを検証する
+func TestCreatePost_BodyTemplate(t *testing.T) {
+	now := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+		var body struct {
+			Post struct {
+				BodyMd string `json:"body_md"`
+			} `json:"post"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return false
+		}
+		assert.Equal(t, "# 2025-05-03の日報\n\n## やったこと\n\n## 明日やること\n\n<a id=\"1300\" href=\"#1300\">13:00</a> 資料を見直す\n\n---\n\n## 気づき\n", body.Post.BodyMd)
+		return true
+	})).Return(jsonResponse(http.StatusCreated, `{}`), nil)
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{
+		TeamName:     "test-team",
+		AccessToken:  "test-token",
+		ScreenName:   "test_user",
+		BodyTemplate: "# {{.Date}}の日報\n\n## やったこと\n\n## 明日やること\n\n## 気づき\n",
+	})
+
+	_, err := client.CreatePost(context.Background(), "資料を見直す", "明日やること", now)
+	assert.NoError(t, err)
+}
+
+// methodIs はHTTPメソッドでリクエストを絞り込むマッチャーを返す
+func methodIs(method string) interface{} {
+	return mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == method
+	})
+}
+
+// jsonResponse は指定したステータスとJSON本文のレスポンスを返す
+func jsonResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// TestUpdatePost_OptimisticConcurrency はoriginal_revisionを使った競合検出と再試行を検証する
+func TestUpdatePost_OptimisticConcurrency(t *testing.T) {
+	now := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+	config := EsaConfig{
+		TeamName:    "test-team",
+		AccessToken: "test-token",
+	}
+	existingPost := &EsaPost{
+		Number:         123,
+		BodyMd:         "既存の内容",
+		RevisionNumber: 5,
+		UpdatedBy:      &EsaUser{ScreenName: "test_user"},
+	}
+
+	// PATCHリクエストの本文
+	type patchBody struct {
+		Post struct {
+			BodyMd           string `json:"body_md"`
+			OriginalRevision *struct {
+				BodyMd string `json:"body_md"`
+				Number int    `json:"number"`
+				User   string `json:"user"`
+			} `json:"original_revision"`
+		} `json:"post"`
+	}
+	decodePatch := func(t *testing.T, req *http.Request) patchBody {
+		var body patchBody
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("original_revisionを送信する", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			body := decodePatch(t, req)
+			require.NotNil(t, body.Post.OriginalRevision)
+			assert.Equal(t, "既存の内容", body.Post.OriginalRevision.BodyMd)
+			assert.Equal(t, 5, body.Post.OriginalRevision.Number)
+			assert.Equal(t, "test_user", body.Post.OriginalRevision.User)
+			return jsonResponse(http.StatusOK, `{"number": 123, "revision_number": 6}`), nil
+		})
+
+		client := NewEsaClient(mockHTTPClient, config)
+		post, err := client.UpdatePost(context.Background(), existingPost, "テスト", "", now)
+		require.NoError(t, err)
+		assert.Equal(t, 6, post.RevisionNumber)
+	})
+
+	t.Run("競合時は最新の投稿に追記し直す", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).Return(jsonResponse(http.StatusConflict, `{}`), nil).Once()
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).Return(jsonResponse(http.StatusOK, `{
+			"number": 123,
+			"body_md": "ブラウザで編集した内容",
+			"revision_number": 7,
+			"updated_by": {"screen_name": "browser_user"}
+		}`), nil).Once()
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			body := decodePatch(t, req)
+			assert.Equal(t, "<a id=\"1300\" href=\"#1300\">13:00</a> テスト\n\n---\n\nブラウザで編集した内容", body.Post.BodyMd)
+			require.NotNil(t, body.Post.OriginalRevision)
+			assert.Equal(t, 7, body.Post.OriginalRevision.Number)
+			assert.Equal(t, "browser_user", body.Post.OriginalRevision.User)
+			return jsonResponse(http.StatusOK, `{"number": 123, "revision_number": 8}`), nil
+		}).Once()
+
+		client := NewEsaClient(mockHTTPClient, config)
+		post, err := client.UpdatePost(context.Background(), existingPost, "テスト", "", now)
+		require.NoError(t, err)
+		assert.Equal(t, 8, post.RevisionNumber)
+	})
+
+	t.Run("競合が続く場合はエラー", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(http.StatusConflict, `{}`), nil
+		}).Times(maxUpdateAttempts)
+		mockHTTPClient.EXPECT().Do(methodIs("GET")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			return jsonResponse(http.StatusOK, `{"number": 123, "body_md": "最新の内容", "revision_number": 9}`), nil
+		}).Times(maxUpdateAttempts - 1)
+
+		client := NewEsaClient(mockHTTPClient, config)
+		_, err := client.UpdatePost(context.Background(), existingPost, "テスト", "", now)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errRevisionConflict))
+		assert.Contains(t, err.Error(), "競合したため日報を更新できませんでした")
+	})
+
+	t.Run("他の編集と自動マージされた場合はそのまま返す", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).Return(jsonResponse(http.StatusOK, `{
+			"number": 123,
+			"body_md": "<a id=\"1300\" href=\"#1300\">13:00</a> テスト\n\n---\n\n既存の内容\nブラウザで追記した内容",
+			"revision_number": 7,
+			"overlapped": false
+		}`), nil).Once()
+
+		client := NewEsaClient(mockHTTPClient, config)
+		post, err := client.UpdatePost(context.Background(), existingPost, "テスト", "", now)
+		require.NoError(t, err)
+		assert.Equal(t, 7, post.RevisionNumber)
+	})
+
+	t.Run("自動マージできなかった場合は保存済みのため再試行せずにエラー", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).Return(jsonResponse(http.StatusOK, `{
+			"number": 123,
+			"body_md": "<<<<<<< HEAD\nブラウザで編集した内容\n=======\n<a id=\"1300\" href=\"#1300\">13:00</a> テスト\n>>>>>>> test_user\n",
+			"revision_number": 7,
+			"overlapped": true
+		}`), nil).Once()
+
+		client := NewEsaClient(mockHTTPClient, config)
+		_, err := client.UpdatePost(context.Background(), existingPost, "テスト", "", now)
+		assert.ErrorIs(t, err, ErrOverlapped)
+		assert.False(t, toolErrorFor(err).Retryable)
+		assert.Contains(t, err.Error(), "日報#123")
+	})
+
+	t.Run("リビジョン番号が不明な場合はoriginal_revisionを送らない", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(methodIs("PATCH")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			body := decodePatch(t, req)
+			assert.Nil(t, body.Post.OriginalRevision)
+			return jsonResponse(http.StatusOK, `{"number": 123}`), nil
+		})
+
+		client := NewEsaClient(mockHTTPClient, config)
+		_, err := client.UpdatePost(context.Background(), &EsaPost{Number: 123, BodyMd: "既存の内容"}, "テスト", "", now)
+		assert.NoError(t, err)
+	})
+}
+
+// TestUpdatePost_UniqueAnchorID は同じ分に追記したとき、既存のエントリと重複しないアンカーIDが付くことを検証する
+func TestUpdatePost_UniqueAnchorID(t *testing.T) {
+	now := time.Date(2025, 5, 3, 12, 34, 30, 0, time.Local)
+
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(methodIs("PATCH")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Post struct {
+				BodyMd string `json:"body_md"`
+			} `json:"post"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "<a id=\"1234-2\" href=\"#1234-2\">12:34</a> 2件目\n\n---\n\n<a id=\"1234\" href=\"#1234\">12:34</a> 1件目\n\n---", body.Post.BodyMd)
+		return jsonResponse(http.StatusOK, `{"number": 123}`), nil
+	})
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{
+		TeamName:    "test-team",
+		AccessToken: "test-token",
+	})
+	existingPost := &EsaPost{Number: 123, BodyMd: "<a id=\"1234\" href=\"#1234\">12:34</a> 1件目\n\n---"}
+	_, err := client.UpdatePost(context.Background(), existingPost, "2件目", "", now)
+	assert.NoError(t, err)
+}
@@ -4,33 +4,77 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // DefaultHandlerFactory は標準的なハンドラーを生成します
-type DefaultHandlerFactory struct{}
+type DefaultHandlerFactory struct {
+	Config EsaConfig
+}
+
+// postPrefix は投稿テキストの先頭から除去するプレフィックス
+var postPrefix = defaultPostPrefix
+
+// NewDefaultHandlerFactory は設定を反映したDefaultHandlerFactoryを生成します
+// デバウンス・プレフィックスの設定はプロセス全体に反映されます
+func NewDefaultHandlerFactory(config EsaConfig) *DefaultHandlerFactory {
+	SetDebounceConfig(config.Debounce.Duration, config.Debounce.SimilarityThreshold)
+	SetRemoteDebounce(config.Debounce.Remote)
+	SetDebounceMetric(config.Debounce.Metric)
+	for _, team := range config.TeamNames() {
+		if profile, err := config.Profile(team); err == nil {
+			SetTeamDebounceConfig(team, profile.Debounce)
+		}
+	}
+	// 履歴の保存先を決められない場合はプロセス内のみで保持する
+	if store, err := NewDebounceStore(config.DebounceStore); err == nil {
+		SetDebounceStore(store)
+	}
+	// 保存先を決められない場合は保留しない
+	if outbox, err := NewOutbox(config.Outbox.Store); err == nil {
+		SetOutbox(outbox)
+	}
+	postPrefix = config.Prefix
+	return &DefaultHandlerFactory{Config: config}
+}
 
-// CreateEsaClient はesa.ioクライアントを生成します
-func (f *DefaultHandlerFactory) CreateEsaClient() (EsaClientInterface, error) {
-	config := ConfigFromEnv()
+// CreateEsaClient は指定したプロファイルのesa.ioクライアントを生成します
+// teamにはResolveTeamで解決したプロファイル名を渡します（空なら共通の設定）
+func (f *DefaultHandlerFactory) CreateEsaClient(team string) (EsaClientInterface, error) {
+	config, err := f.Config.Profile(team)
+	if err != nil {
+		return nil, err
+	}
 	if config.TeamName == "" || config.AccessToken == "" {
-		return nil, errors.New("ESA_TEAM_NAME または ESA_ACCESS_TOKEN が設定されていません")
+		if team != "" {
+			return nil, newToolError(ErrNotConfigured, "チーム%sのteam_nameまたはaccess_tokenが設定されていません", team)
+		}
+		return nil, newToolError(ErrNotConfigured, "ESA_TEAM_NAME または ESA_ACCESS_TOKEN が設定されていません")
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
 	}
-	httpClient := NewHTTPClient(10 * time.Second)
+	httpClient := NewRetryHTTPClient(NewHTTPClient(timeout))
 	return NewEsaClient(httpClient, config), nil
 }
 
 // submitDailyReportWithClock は日報を投稿するハンドラー（時間指定可能、テスト用）
+// 検索・作成・更新のすべてで同じ時刻nowを使い、日付の境界をまたいでも同じ日の日報を対象にする
 func submitDailyReportWithClock(ctx context.Context, _ *mcp.ServerSession, params *TimesEsaPostRequest, esaClient EsaClientInterface, now time.Time) (*TimesEsaPostResponse, error) {
+	// 投稿時刻は日報のタイムゾーンで扱う
+	boundary := dayBoundaryOf(esaClient)
+	now = boundary.In(now)
 
 	// パラメーターの取得
 	text := params.Text
 
 	// 空文字チェック
 	if text == "" {
-		return nil, fmt.Errorf("text parameter cannot be empty")
+		return nil, newToolError(ErrInvalidParams, "text parameter cannot be empty")
 	}
 
 	// confirmed_by_userパラメータの確認
@@ -38,62 +82,174 @@ func submitDailyReportWithClock(ctx context.Context, _ *mcp.ServerSession, param
 
 	// ユーザーによる確認が取れていない場合はエラーで停止
 	if !confirmedByUser {
-		return nil, fmt.Errorf("投稿前にユーザーによる内容の確認が必要です。内容の確認をユーザーに行ったら、confirmed_by_user=trueを設定してください")
+		return nil, newToolError(ErrNotConfirmed, "投稿前にユーザーによる内容の確認が必要です。内容の確認をユーザーに行ったら、confirmed_by_user=trueを設定してください")
 	}
 
 	// #times-esa除去（prefix自体と直後の空白のみ除去、他は一切変更しない）
-	text = stripPrefix(text, "#times-esa")
+	if postPrefix != "" {
+		text = stripPrefix(text, postPrefix)
+	}
 
 	// debounceチェック - 同じテキストが短時間内に複数回送信されたら拒否
-	if isDebounced(text) {
+	// チームごとに判定する
+	if isDebouncedForTeam(params.Team, text) {
 		// デバウンス時間を秒単位でメッセージに含める
-		debounceSeconds := int(debounceConfig.Duration.Seconds())
-		return nil, fmt.Errorf("%d秒以内に同じ内容の投稿が行われました。しばらく待ってから再試行してください", debounceSeconds)
+		return nil, debouncedError(params.Team, "%d秒以内に同じ内容の投稿が行われました。しばらく待ってから再試行してください")
+	}
+
+	// 日付・時刻の指定があればその時刻に、なければ現在時刻に投稿する
+	postedAt, err := parsePostTime(params.Date, params.Time, now, boundary)
+	if err != nil {
+		return nil, err
 	}
+	backdated := !postedAt.Equal(now)
+
+	// esa.ioに接続できない場合にアウトボックスに保留する投稿
+	pending := OutboxItem{Team: params.Team, Text: text, Section: params.Section, CreatedAt: postedAt}
 
 	// 日付ベースのカテゴリを生成
-	category := fmt.Sprintf("日報/%04d/%02d/%02d", now.Year(), now.Month(), now.Day())
+	category, err := esaClient.DailyReportCategory(ctx, postedAt)
+	if err != nil {
+		err = fmt.Errorf("カテゴリーの生成に失敗しました: %w", err)
+		return queueFailedPost(ctx, pending, err)
+	}
+	pending.Category = category
 
 	// 既存の投稿を検索
-	existingPost, err := esaClient.SearchPostByCategory(category)
+	existingPost, err := esaClient.ResolveDailyReport(ctx, category)
 	if err != nil {
-		return nil, fmt.Errorf("投稿の検索に失敗しました: %w", err)
+		err = fmt.Errorf("投稿の検索に失敗しました: %w", err)
+		return queueFailedPost(ctx, pending, err)
+	}
+
+	// 他の端末などから投稿された同じ内容のエントリが既存の日報にあれば拒否
+	// 過去の時刻の投稿は直前のエントリとの重複ではないため比較しない
+	if existingPost != nil && !backdated {
+		if entry, ok := isRemoteDuplicate(params.Team, existingPost.BodyMd, text, now); ok {
+			return nil, debouncedError(params.Team, "%d秒以内に同じ内容のエントリが日報にあります（%s #%s）。しばらく待ってから再試行してください", entry.Time, entry.AnchorID)
+		}
 	}
 
 	var post *EsaPost
-	if existingPost == nil {
+	switch {
+	case existingPost == nil:
 		// 新しい投稿を作成
-		post, err = esaClient.CreatePost(text)
+		post, err = esaClient.CreatePost(ctx, text, params.Section, postedAt)
 		if err != nil {
-			return nil, fmt.Errorf("新規投稿の作成に失敗しました: %w", err)
+			err = fmt.Errorf("新規投稿の作成に失敗しました: %w", err)
+			return queueFailedPost(ctx, pending, err)
 		}
-	} else {
+	case backdated:
+		// 過去の時刻の投稿は既存のエントリの時刻順の位置に挿入
+		post, err = esaClient.InsertEntry(ctx, existingPost, text, params.Section, postedAt)
+		if err != nil {
+			err = fmt.Errorf("投稿の更新に失敗しました: %w", err)
+			return queueFailedPost(ctx, pending, err)
+		}
+	default:
 		// 既存の投稿を更新（テキストのみ）
-		post, err = esaClient.UpdatePost(existingPost, text)
+		post, err = esaClient.UpdatePost(ctx, existingPost, text, params.Section, now)
 		if err != nil {
-			return nil, fmt.Errorf("投稿の更新に失敗しました: %w", err)
+			err = fmt.Errorf("投稿の更新に失敗しました: %w", err)
+			return queueFailedPost(ctx, pending, err)
 		}
 	}
 
 	// レスポンスを返す
-	return &TimesEsaPostResponse{
+	response := &TimesEsaPostResponse{
 		Success: true,
 		Message: "日報を投稿しました",
 		Post:    *post,
+	}
+
+	// 見出しが手で消されていた場合は、見出しの外に書いたことを伝える
+	if params.Section != "" && !HasDailyReportSection(post.BodyMd, params.Section) {
+		response.Message += fmt.Sprintf("（見出し「%s」が見つからなかったため、見出しの外に書きました）", params.Section)
+	}
+
+	// レート制限の残りが少ない場合は警告を添える
+	if reporter, ok := esaClient.(RateLimitReporter); ok {
+		if rateLimit, ok := reporter.RateLimit(); ok {
+			response.RateLimit = &rateLimit
+			if rateLimit.Remaining <= rateLimitWarningThreshold {
+				response.Message += fmt.Sprintf("（esa.io APIの残りリクエスト数が%d回です。%sにリセットされます）",
+					rateLimit.Remaining, rateLimit.Reset.In(now.Location()).Format("15:04"))
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// queueFailedPost はesa.ioに接続できない一時的な障害で投稿できなかった場合に、投稿pendingをアウトボックスに保留する
+// 保留できた場合は成功として返し、それ以外の場合はcauseをそのまま返す
+// 呼び出しがキャンセルされた場合は、後から投稿しないように保留しない
+func queueFailedPost(ctx context.Context, pending OutboxItem, cause error) (*TimesEsaPostResponse, error) {
+	if ctx.Err() != nil || errors.Is(cause, context.Canceled) {
+		return nil, cause
+	}
+
+	var uncertain *uncertainWriteError
+	if errors.As(cause, &uncertain) {
+		return nil, fmt.Errorf("%w（esa.ioに保存された可能性があるため保留しませんでした。日報を確認してからやり直してください）", cause)
+	}
+
+	outbox := currentOutbox()
+	if outbox == nil || !isOutboxRetryable(cause) {
+		return nil, cause
+	}
+
+	pending.LastError = cause.Error()
+	item, err := outbox.Enqueue(pending)
+	if err != nil {
+		return nil, fmt.Errorf("%w（アウトボックスへの保存にも失敗しました: %v）", cause, err)
+	}
+
+	return &TimesEsaPostResponse{
+		Success: true,
+		Message: fmt.Sprintf("esa.ioに投稿できなかったため、投稿を保留しました（%s）。接続が回復したら%sの日報に自動で投稿します", item.ID, item.CreatedAt.Format("2006-01-02 15:04")),
+		Queued:  &item,
 	}, nil
 }
 
+// debouncedError はデバウンスで投稿を拒否したことを表すエラーを返す
+// formatの最初の%dにはデバウンスの時間（秒）が入り、やり直すまでの時間としても返す
+func debouncedError(team, format string, args ...any) error {
+	duration := DebounceDuration(team)
+	err := newToolError(ErrDebounced, format, append([]any{int(duration.Seconds())}, args...)...)
+	err.RetryAfter = duration
+	return err
+}
+
+// toolErrorResult はツールの呼び出しが失敗したことを表すCallToolResultを返す
+// 失敗の理由をIsErrorとともにstructured contentとして返し、クライアントがエラーの種類で対応を変えられるようにする
+func toolErrorResult(err error) (*mcp.CallToolResult, any, error) {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: err.Error(),
+			},
+		},
+	}, newToolErrorResponse(err), nil
+}
+
 // submitDailyReportHandler は日報を投稿するハンドラー
-func submitDailyReportHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaPostRequest) (*mcp.CallToolResult, any, error) {
-	factory := &DefaultHandlerFactory{}
-	esaClient, err := factory.CreateEsaClient()
+func (f *DefaultHandlerFactory) submitDailyReportHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaPostRequest) (*mcp.CallToolResult, any, error) {
+	team, err := f.Config.ResolveTeam(params.Team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+	params.Team = team
+
+	esaClient, err := f.CreateEsaClient(team)
 	if err != nil {
-		return nil, nil, err
+		return toolErrorResult(err)
 	}
 
-	result, err := submitDailyReportWithClock(ctx, nil, &params, esaClient, time.Now())
+	result, err := submitDailyReportWithClock(ctx, nil, &params, esaClient, defaultClock.Now())
 	if err != nil {
-		return nil, nil, err
+		return toolErrorResult(err)
 	}
 
 	return &mcp.CallToolResult{
@@ -104,3 +260,676 @@ func submitDailyReportHandler(ctx context.Context, req *mcp.CallToolRequest, par
 		},
 	}, result, nil
 }
+
+// readDailyReportWithClock は指定日の日報をエントリ単位で取得するハンドラー（時間指定可能、テスト用）
+func readDailyReportWithClock(ctx context.Context, params *TimesEsaReadRequest, esaClient EsaClientInterface, now time.Time) (*TimesEsaReadResponse, error) {
+	// 日付の決定（未指定の場合は今日）
+	boundary := dayBoundaryOf(esaClient)
+	date, err := parseReportDate(params.Date, now, boundary)
+	if err != nil {
+		return nil, err
+	}
+
+	// 日付ベースのカテゴリを生成
+	category, err := esaClient.DailyReportCategory(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("カテゴリーの生成に失敗しました: %w", err)
+	}
+
+	// 既存の投稿を検索
+	post, err := esaClient.SearchPostByCategory(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("投稿の検索に失敗しました: %w", err)
+	}
+
+	response := &TimesEsaReadResponse{
+		Success:  true,
+		Date:     formatReportDate(date, boundary),
+		Category: category,
+		Entries:  []DailyReportEntry{},
+	}
+
+	if post == nil {
+		response.Message = fmt.Sprintf("%sの日報はまだありません", response.Date)
+		return response, nil
+	}
+
+	response.Entries = ParseDailyReportEntries(post.BodyMd)
+	response.Message = fmt.Sprintf("%sの日報には%d件のエントリがあります", response.Date, len(response.Entries))
+	return response, nil
+}
+
+// readDailyReportHandler は日報を読み取るハンドラー
+func (f *DefaultHandlerFactory) readDailyReportHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaReadRequest) (*mcp.CallToolResult, any, error) {
+	team, err := f.Config.ResolveTeam(params.Team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	esaClient, err := f.CreateEsaClient(team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	result, err := readDailyReportWithClock(ctx, &params, esaClient, defaultClock.Now())
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	// エントリを人が読める形式でも返す
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: formatReadResponse(result),
+			},
+		},
+	}, result, nil
+}
+
+// formatReadResponse は日報のエントリを人が読める形式にする
+func formatReadResponse(result *TimesEsaReadResponse) string {
+	lines := []string{result.Message}
+	for _, entry := range result.Entries {
+		if entry.AnchorID == "" {
+			lines = append(lines, entry.Text)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (#%s) %s", entry.Time, entry.AnchorID, entry.Text))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// parseReportDate は日付の指定（YYYY-MM-DD形式）を解析する（未指定の場合は今日）
+// 指定した日付はその日の日報の開始時刻（日付が変わる時刻）にする
+func parseReportDate(date string, now time.Time, boundary DayBoundary) (time.Time, error) {
+	if date == "" {
+		return boundary.In(now), nil
+	}
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, newToolError(ErrInvalidParams, "dateはYYYY-MM-DD形式で指定してください: %w", err)
+	}
+	return boundary.DayStart(parsed.Date()), nil
+}
+
+// parsePostTime は投稿する日付（YYYY-MM-DD形式）と時刻（HH:MM形式）の指定から投稿時刻を求める
+// 指定がない場合はnowを返す。dateを省略した場合は今日の日報、timeは日報の日の中の時刻とみなす（日付が変わる時刻より前なら翌日の時刻）
+// 投稿時刻はエントリの時刻として書くため、過去の日付ではtimeを省略できない（今日の日付ならnowを返す）
+func parsePostTime(date, clock string, now time.Time, boundary DayBoundary) (time.Time, error) {
+	if date == "" && clock == "" {
+		return now, nil
+	}
+	if clock == "" {
+		day, err := parseReportDate(date, now, boundary)
+		if err != nil {
+			return time.Time{}, err
+		}
+		today := boundary.ReportDay(now)
+		switch {
+		case day.After(today):
+			return time.Time{}, newToolError(ErrInvalidParams, "未来の日付（%s）には投稿できません", date)
+		case day.Before(today):
+			return time.Time{}, newToolError(ErrInvalidParams, "過去の日報（%s）に書く場合はtimeでエントリの時刻も指定してください", date)
+		}
+		return now, nil
+	}
+	parsedClock, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, newToolError(ErrInvalidParams, "timeはHH:MM形式で指定してください: %w", err)
+	}
+
+	day := boundary.ReportDay(now)
+	if date != "" {
+		day, err = parseReportDate(date, now, boundary)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	year, month, dayOfMonth := day.Date()
+	if parsedClock.Hour() < boundary.RolloverHour {
+		dayOfMonth++
+	}
+	postedAt := time.Date(year, month, dayOfMonth, parsedClock.Hour(), parsedClock.Minute(), 0, 0, day.Location())
+	if postedAt.After(now) {
+		return time.Time{}, newToolError(ErrInvalidParams, "未来の時刻（%s）には投稿できません", postedAt.Format("2006-01-02 15:04"))
+	}
+	return postedAt, nil
+}
+
+// formatReportDate はtが属する日報の日付をYYYY-MM-DD形式で返す
+func formatReportDate(t time.Time, boundary DayBoundary) string {
+	return boundary.ReportDay(t).Format("2006-01-02")
+}
+
+// dayBoundaryOf はesaClientの設定の日報の1日の区切りを返す（報告しない場合はデフォルトの区切り）
+func dayBoundaryOf(esaClient EsaClientInterface) DayBoundary {
+	if reporter, ok := esaClient.(DayBoundaryReporter); ok {
+		return reporter.DayBoundary()
+	}
+	return defaultDayBoundary
+}
+
+// findExistingDailyReport は編集対象の日報を検索する（存在しない場合はエラー）
+func findExistingDailyReport(ctx context.Context, esaClient EsaClientInterface, dateParam string, now time.Time) (*EsaPost, error) {
+	boundary := dayBoundaryOf(esaClient)
+	date, err := parseReportDate(dateParam, now, boundary)
+	if err != nil {
+		return nil, err
+	}
+
+	// 日付ベースのカテゴリを生成
+	category, err := esaClient.DailyReportCategory(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("カテゴリーの生成に失敗しました: %w", err)
+	}
+
+	// 既存の投稿を検索
+	post, err := esaClient.SearchPostByCategory(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("投稿の検索に失敗しました: %w", err)
+	}
+	if post == nil {
+		return nil, newToolError(ErrNotFound, "%sの日報はまだありません", formatReportDate(date, boundary))
+	}
+	return post, nil
+}
+
+// validateEntryTarget はエントリの指定（anchor_idまたはindex）を検証する
+func validateEntryTarget(anchorID string, index int) error {
+	if anchorID == "" && index == 0 {
+		return newToolError(ErrInvalidParams, "anchor_idまたはindexを指定してください")
+	}
+	if index < 0 {
+		return newToolError(ErrInvalidParams, "indexは1以上を指定してください: %d", index)
+	}
+	return nil
+}
+
+// editDailyReportEntryWithClock は日報のエントリ1件の本文を置き換えるハンドラー（時間指定可能、テスト用）
+func editDailyReportEntryWithClock(ctx context.Context, params *TimesEsaEditEntryRequest, esaClient EsaClientInterface, now time.Time) (*TimesEsaEntryResponse, error) {
+	text := params.Text
+	if text == "" {
+		return nil, newToolError(ErrInvalidParams, "text parameter cannot be empty")
+	}
+
+	// ユーザーによる確認が取れていない場合はエラーで停止
+	if !params.ConfirmedByUser {
+		return nil, newToolError(ErrNotConfirmed, "編集前にユーザーによる内容の確認が必要です。内容の確認をユーザーに行ったら、confirmed_by_user=trueを設定してください")
+	}
+
+	if err := validateEntryTarget(params.AnchorID, params.Index); err != nil {
+		return nil, err
+	}
+
+	// #times-esa除去
+	if postPrefix != "" {
+		text = stripPrefix(text, postPrefix)
+	}
+
+	existingPost, err := findExistingDailyReport(ctx, esaClient, params.Date, now)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := FindDailyReportEntry(existingPost.BodyMd, params.AnchorID, params.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	// 競合して取得し直した場合も同じアンカーのエントリだけを書き換える
+	post, err := esaClient.EditPost(ctx, existingPost, func(bodyMd string) (string, error) {
+		return ReplaceDailyReportEntry(bodyMd, entry.AnchorID, params.Index, text)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("エントリの編集に失敗しました: %w", err)
+	}
+
+	entry.Text = text
+	return &TimesEsaEntryResponse{
+		Success: true,
+		Message: fmt.Sprintf("%s (#%s) のエントリを編集しました", entry.Time, entry.AnchorID),
+		Entry:   entry,
+		Post:    *post,
+	}, nil
+}
+
+// deleteDailyReportEntryWithClock は日報のエントリ1件を削除するハンドラー（時間指定可能、テスト用）
+func deleteDailyReportEntryWithClock(ctx context.Context, params *TimesEsaDeleteEntryRequest, esaClient EsaClientInterface, now time.Time) (*TimesEsaEntryResponse, error) {
+	// ユーザーによる確認が取れていない場合はエラーで停止
+	if !params.ConfirmedByUser {
+		return nil, newToolError(ErrNotConfirmed, "削除前にユーザーによる内容の確認が必要です。内容の確認をユーザーに行ったら、confirmed_by_user=trueを設定してください")
+	}
+
+	if err := validateEntryTarget(params.AnchorID, params.Index); err != nil {
+		return nil, err
+	}
+
+	existingPost, err := findExistingDailyReport(ctx, esaClient, params.Date, now)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := FindDailyReportEntry(existingPost.BodyMd, params.AnchorID, params.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	// 競合して取得し直した場合も同じアンカーのエントリだけを削除する
+	post, err := esaClient.EditPost(ctx, existingPost, func(bodyMd string) (string, error) {
+		return RemoveDailyReportEntry(bodyMd, entry.AnchorID, params.Index)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("エントリの削除に失敗しました: %w", err)
+	}
+
+	return &TimesEsaEntryResponse{
+		Success: true,
+		Message: fmt.Sprintf("%s (#%s) のエントリを削除しました", entry.Time, entry.AnchorID),
+		Entry:   entry,
+		Post:    *post,
+	}, nil
+}
+
+// editDailyReportEntryHandler は日報のエントリを編集するハンドラー
+func (f *DefaultHandlerFactory) editDailyReportEntryHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaEditEntryRequest) (*mcp.CallToolResult, any, error) {
+	team, err := f.Config.ResolveTeam(params.Team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	esaClient, err := f.CreateEsaClient(team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	result, err := editDailyReportEntryWithClock(ctx, &params, esaClient, defaultClock.Now())
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: result.Message,
+			},
+		},
+	}, result, nil
+}
+
+// deleteDailyReportEntryHandler は日報のエントリを削除するハンドラー
+func (f *DefaultHandlerFactory) deleteDailyReportEntryHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaDeleteEntryRequest) (*mcp.CallToolResult, any, error) {
+	team, err := f.Config.ResolveTeam(params.Team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	esaClient, err := f.CreateEsaClient(team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	result, err := deleteDailyReportEntryWithClock(ctx, &params, esaClient, defaultClock.Now())
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: result.Message,
+			},
+		},
+	}, result, nil
+}
+
+// duplicateDailyReportsWithClock は同じ日の重複した日報を一覧し、指定があれば1件にまとめるハンドラー（時間指定可能、テスト用）
+func duplicateDailyReportsWithClock(ctx context.Context, params *TimesEsaDuplicatesRequest, esaClient EsaClientInterface, now time.Time) (*TimesEsaDuplicatesResponse, error) {
+	keep := params.Keep
+	if keep == "" {
+		keep = duplicatePolicyOldest
+	}
+	if keep != duplicatePolicyOldest && keep != duplicatePolicyNewest {
+		return nil, newToolError(ErrInvalidParams, "keepは%sまたは%sを指定してください: %s", duplicatePolicyOldest, duplicatePolicyNewest, keep)
+	}
+
+	// ユーザーによる確認が取れていない場合はエラーで停止
+	if params.Repair && !params.ConfirmedByUser {
+		return nil, newToolError(ErrNotConfirmed, "日報をまとめる前にユーザーによる確認が必要です。まとめる日報をユーザーに確認したら、confirmed_by_user=trueを設定してください")
+	}
+
+	boundary := dayBoundaryOf(esaClient)
+	date, err := parseReportDate(params.Date, now, boundary)
+	if err != nil {
+		return nil, err
+	}
+
+	// 日付ベースのカテゴリを生成
+	category, err := esaClient.DailyReportCategory(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("カテゴリーの生成に失敗しました: %w", err)
+	}
+
+	posts, err := esaClient.SearchPostsByCategory(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("投稿の検索に失敗しました: %w", err)
+	}
+
+	response := &TimesEsaDuplicatesResponse{
+		Success:  true,
+		Date:     formatReportDate(date, boundary),
+		Category: category,
+		Posts:    make([]EsaSearchPostItem, 0, len(posts)),
+	}
+	for _, post := range posts {
+		response.Posts = append(response.Posts, EsaSearchPostItem{
+			Number:   post.Number,
+			Name:     post.Name,
+			Category: post.Category,
+			Tags:     post.Tags,
+			Excerpt:  truncateRunes(strings.TrimSpace(post.BodyMd), searchExcerptLength),
+		})
+	}
+
+	if len(posts) < 2 {
+		response.Message = fmt.Sprintf("%sの日報は%d件で、重複はありません", response.Date, len(posts))
+		return response, nil
+	}
+	if !params.Repair {
+		response.Message = fmt.Sprintf("%sの日報が%d件あります（%s）。repair=trueで1件にまとめられます", response.Date, len(posts), postNumbers(posts))
+		return response, nil
+	}
+
+	// 残す日報に、それ以外の日報の本文を古い順にまとめる
+	primary := posts[0]
+	duplicates := posts[1:]
+	if keep == duplicatePolicyNewest {
+		primary = posts[len(posts)-1]
+		duplicates = posts[:len(posts)-1]
+	}
+
+	post, err := esaClient.MergePosts(ctx, &primary, duplicates)
+	if err != nil {
+		return nil, fmt.Errorf("日報をまとめられませんでした: %w", err)
+	}
+
+	response.Post = post
+	for _, duplicate := range duplicates {
+		response.Archived = append(response.Archived, duplicate.Number)
+	}
+	response.Message = fmt.Sprintf("%sの日報を#%dにまとめ、%sをアーカイブしました", response.Date, post.Number, postNumbers(duplicates))
+	return response, nil
+}
+
+// duplicateDailyReportsHandler は重複した日報を一覧・修復するハンドラー
+func (f *DefaultHandlerFactory) duplicateDailyReportsHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaDuplicatesRequest) (*mcp.CallToolResult, any, error) {
+	team, err := f.Config.ResolveTeam(params.Team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	esaClient, err := f.CreateEsaClient(team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	result, err := duplicateDailyReportsWithClock(ctx, &params, esaClient, defaultClock.Now())
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	// 日報の一覧を人が読める形式でも返す
+	lines := []string{result.Message}
+	for _, post := range result.Posts {
+		lines = append(lines, fmt.Sprintf("#%d %s/%s", post.Number, post.Category, post.Name))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: strings.Join(lines, "\n"),
+			},
+		},
+	}, result, nil
+}
+
+// アウトボックスの操作（TimesEsaOutboxRequest.Actionで指定する）
+const (
+	outboxActionList  = "list"
+	outboxActionFlush = "flush"
+	outboxActionDrop  = "drop"
+)
+
+// manageOutbox は保留している投稿を一覧・再投稿・取り消しするハンドラー（クライアントの生成方法を指定可能、テスト用）
+func manageOutbox(ctx context.Context, params *TimesEsaOutboxRequest, outbox *Outbox, newClient func(team string) (EsaClientInterface, error)) (*TimesEsaOutboxResponse, error) {
+	if outbox == nil {
+		return nil, newToolError(ErrNotConfigured, "アウトボックスが無効になっています（outbox.storeがoff）")
+	}
+
+	response := &TimesEsaOutboxResponse{Success: true}
+	switch params.Action {
+	case "", outboxActionList:
+	case outboxActionFlush:
+		posted, err := outbox.Flush(ctx, newClient)
+		if err != nil {
+			return nil, err
+		}
+		response.Posted = posted
+	case outboxActionDrop:
+		if len(params.IDs) == 0 {
+			return nil, newToolError(ErrInvalidParams, "取り消す投稿のidsを指定してください")
+		}
+		// ユーザーによる確認が取れていない場合はエラーで停止
+		if !params.ConfirmedByUser {
+			return nil, newToolError(ErrNotConfirmed, "取り消す前にユーザーによる確認が必要です。取り消す投稿をユーザーに確認したら、confirmed_by_user=trueを設定してください")
+		}
+		dropped, err := outbox.Drop(params.IDs)
+		if err != nil {
+			return nil, err
+		}
+		response.Dropped = dropped
+	default:
+		return nil, newToolError(ErrInvalidParams, "actionは%s・%s・%sのいずれかを指定してください: %s", outboxActionList, outboxActionFlush, outboxActionDrop, params.Action)
+	}
+
+	items, err := outbox.List()
+	if err != nil {
+		return nil, err
+	}
+	response.Items = items
+	if response.Items == nil {
+		response.Items = []OutboxItem{}
+	}
+
+	var messages []string
+	if params.Action == outboxActionFlush {
+		messages = append(messages, fmt.Sprintf("%d件の保留した投稿を投稿しました", len(response.Posted)))
+	}
+	if params.Action == outboxActionDrop {
+		messages = append(messages, fmt.Sprintf("%d件の保留した投稿を取り消しました", len(response.Dropped)))
+	}
+	messages = append(messages, fmt.Sprintf("保留している投稿は%d件です", len(response.Items)))
+	response.Message = strings.Join(messages, "。")
+	return response, nil
+}
+
+// outboxHandler は保留している投稿を扱うハンドラー
+func (f *DefaultHandlerFactory) outboxHandler(ctx context.Context, req *mcp.CallToolRequest, params TimesEsaOutboxRequest) (*mcp.CallToolResult, any, error) {
+	result, err := manageOutbox(ctx, &params, currentOutbox(), f.CreateEsaClient)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	// 保留している投稿を人が読める形式でも返す
+	lines := []string{result.Message}
+	for _, item := range result.Items {
+		line := fmt.Sprintf("%s %s %s", item.ID, item.CreatedAt.Format("2006-01-02 15:04"), item.Text)
+		if item.LastError != "" {
+			line += fmt.Sprintf("\n（%d回失敗: %s）", item.Attempts, item.LastError)
+		}
+		lines = append(lines, line)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: strings.Join(lines, "\n\n"),
+			},
+		},
+	}, result, nil
+}
+
+// searchExcerptLength は検索結果に含める本文抜粋の最大文字数
+const searchExcerptLength = 200
+
+// buildSearchOptions は検索リクエストをSearchOptionのスライスに変換する
+func buildSearchOptions(params *EsaSearchRequest) ([]SearchOption, error) {
+	var options []SearchOption
+
+	if params.CategoryPrefix != "" {
+		options = append(options, WithCategoryPrefix(params.CategoryPrefix))
+	}
+	if len(params.Keywords) > 0 {
+		options = append(options, WithKeywords(params.Keywords...))
+	}
+	if len(params.Tags) > 0 {
+		options = append(options, WithTags(params.Tags...))
+	}
+	if params.User != "" {
+		options = append(options, WithUser(params.User))
+	}
+
+	// 日付範囲
+	if params.DateFrom != "" || params.DateTo != "" {
+		field := params.DateField
+		if field == "" {
+			field = "created"
+		}
+		if field != "created" && field != "updated" {
+			return nil, newToolError(ErrInvalidParams, "date_fieldにはcreatedまたはupdatedを指定してください: %s", field)
+		}
+		var from, to time.Time
+		var err error
+		if params.DateFrom != "" {
+			if from, err = time.Parse("2006-01-02", params.DateFrom); err != nil {
+				return nil, newToolError(ErrInvalidParams, "date_fromはYYYY-MM-DD形式で指定してください: %w", err)
+			}
+		}
+		if params.DateTo != "" {
+			if to, err = time.Parse("2006-01-02", params.DateTo); err != nil {
+				return nil, newToolError(ErrInvalidParams, "date_toはYYYY-MM-DD形式で指定してください: %w", err)
+			}
+		}
+		options = append(options, WithDateRange(field, from, to))
+	}
+
+	if params.WIP != nil {
+		options = append(options, WithWIP(*params.WIP))
+	}
+	if params.Starred != nil {
+		options = append(options, WithStarred(*params.Starred))
+	}
+
+	// ソート（未指定の項目はSearchのデフォルト値を使う）
+	if params.Sort != "" || params.Order != "" {
+		sort, order := params.Sort, params.Order
+		if sort == "" {
+			sort = "updated"
+		}
+		if order == "" {
+			order = "desc"
+		}
+		options = append(options, WithSort(sort, order))
+	}
+
+	// ページネーション（per_pageはAPIの上限100に丸める）
+	if params.Page > 0 || params.PerPage > 0 {
+		page, perPage := params.Page, params.PerPage
+		if page <= 0 {
+			page = 1
+		}
+		if perPage <= 0 {
+			perPage = 20
+		}
+		options = append(options, WithPagination(page, min(perPage, 100)))
+	}
+
+	return options, nil
+}
+
+// searchPosts はesa.ioの投稿を検索するハンドラー（クライアント指定可能、テスト用）
+func searchPosts(ctx context.Context, params *EsaSearchRequest, esaClient EsaClientInterface) (*EsaSearchResponse, error) {
+	options, err := buildSearchOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := esaClient.Search(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("投稿の検索に失敗しました: %w", err)
+	}
+
+	posts := make([]EsaSearchPostItem, 0, len(result.Posts))
+	for _, post := range result.Posts {
+		posts = append(posts, EsaSearchPostItem{
+			Number:   post.Number,
+			Name:     post.Name,
+			Category: post.Category,
+			Tags:     post.Tags,
+			Excerpt:  truncateRunes(strings.TrimSpace(post.BodyMd), searchExcerptLength),
+		})
+	}
+
+	return &EsaSearchResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("%d件中%d件の投稿が見つかりました", result.TotalCount, len(posts)),
+		TotalCount: result.TotalCount,
+		Posts:      posts,
+	}, nil
+}
+
+// searchPostsHandler はesa.ioの投稿を検索するハンドラー
+func (f *DefaultHandlerFactory) searchPostsHandler(ctx context.Context, req *mcp.CallToolRequest, params EsaSearchRequest) (*mcp.CallToolResult, any, error) {
+	team, err := f.Config.ResolveTeam(params.Team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	esaClient, err := f.CreateEsaClient(team)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	result, err := searchPosts(ctx, &params, esaClient)
+	if err != nil {
+		return toolErrorResult(err)
+	}
+
+	// 検索結果を人が読める形式でも返す
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: formatSearchResponse(result),
+			},
+		},
+	}, result, nil
+}
+
+// formatSearchResponse は検索結果を人が読める形式にする
+func formatSearchResponse(result *EsaSearchResponse) string {
+	lines := []string{result.Message}
+	for _, post := range result.Posts {
+		title := post.Name
+		if post.Category != "" {
+			title = post.Category + "/" + post.Name
+		}
+		line := fmt.Sprintf("#%d %s", post.Number, title)
+		if len(post.Tags) > 0 {
+			line += " [" + strings.Join(post.Tags, ", ") + "]"
+		}
+		if post.Excerpt != "" {
+			line += "\n" + post.Excerpt
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n\n")
+}
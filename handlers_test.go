@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"errors"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,8 +34,9 @@ func TestSubmitDailyReport(t *testing.T) {
 		}
 
 		// モックの振る舞いを設定
-		mockEsaClient.EXPECT().SearchPostByCategory("日報/2025/05/03").Return(nil, nil)
-		mockEsaClient.EXPECT().CreatePost(testText).Return(mockPost, nil)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, testText, "", fixedTime).Return(mockPost, nil)
 
 		// リクエスト作成
 		req := &TimesEsaPostRequest{
@@ -71,8 +76,9 @@ func TestSubmitDailyReport(t *testing.T) {
 		}
 
 		// モックの振る舞いを設定
-		mockEsaClient.EXPECT().SearchPostByCategory("日報/2025/05/03").Return(existingPost, nil)
-		mockEsaClient.EXPECT().UpdatePost(existingPost, testText).Return(updatedPost, nil)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, existingPost, testText, "", fixedTime).Return(updatedPost, nil)
 
 		// リクエスト作成
 		req := &TimesEsaPostRequest{
@@ -90,6 +96,71 @@ func TestSubmitDailyReport(t *testing.T) {
 		assert.Contains(t, result.Message, "日報を投稿しました")
 	})
 
+	t.Run("日付境界テスト", func(t *testing.T) {
+		// 各テストケース前にdebounceをリセット
+		resetDebounce()
+
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// 日付が変わる直前の時刻
+		beforeMidnight := time.Date(2025, 5, 15, 23, 59, 59, 999_000_000, time.Local)
+		mockPost := &EsaPost{Number: 123}
+
+		// 検索と作成の両方に同じ時刻が使われることを検証
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, beforeMidnight).Return("日報/2025/05/15", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/15").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "日付境界の投稿", "", beforeMidnight).Return(mockPost, nil)
+
+		// リクエスト作成
+		req := &TimesEsaPostRequest{
+			Text:            "日付境界の投稿",
+			ConfirmedByUser: true,
+		}
+
+		// テスト対象の関数を実行
+		result, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, beforeMidnight)
+
+		// 検証
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, mockPost.Number, result.Post.Number)
+	})
+
+	t.Run("レート制限の警告テスト", func(t *testing.T) {
+		// 各テストケース前にdebounceをリセット
+		resetDebounce()
+
+		// レート制限の状況を報告するクライアント
+		mockEsaClient := &rateLimitedEsaClient{
+			MockEsaClientInterface: NewMockEsaClientInterface(t),
+			rateLimit: RateLimit{
+				Limit:     75,
+				Remaining: 3,
+				Reset:     time.Date(2025, 5, 3, 13, 15, 0, 0, time.Local),
+			},
+		}
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "テスト内容", "", fixedTime).Return(&EsaPost{Number: 123}, nil)
+
+		// リクエスト作成
+		req := &TimesEsaPostRequest{
+			Text:            "テスト内容",
+			ConfirmedByUser: true,
+		}
+
+		// テスト対象の関数を実行
+		result, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+
+		// 残りリクエスト数の警告が含まれることを検証
+		require.NoError(t, err)
+		require.NotNil(t, result.RateLimit)
+		assert.Equal(t, 3, result.RateLimit.Remaining)
+		assert.Contains(t, result.Message, "残りリクエスト数が3回です")
+		assert.Contains(t, result.Message, "13:15にリセット")
+	})
+
 	t.Run("検索エラーテスト", func(t *testing.T) {
 		// 各テストケース前にdebounceをリセット
 		resetDebounce()
@@ -98,7 +169,8 @@ func TestSubmitDailyReport(t *testing.T) {
 		mockEsaClient := NewMockEsaClientInterface(t)
 
 		// モックの振る舞いを設定
-		mockEsaClient.EXPECT().SearchPostByCategory("日報/2025/05/03").Return(nil, errors.New("API接続エラー"))
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, errors.New("API接続エラー"))
 
 		// リクエスト作成
 		req := &TimesEsaPostRequest{
@@ -131,8 +203,9 @@ func TestSubmitDailyReport(t *testing.T) {
 		}
 
 		// モックの振る舞いを設定
-		mockEsaClient.EXPECT().SearchPostByCategory("日報/2025/05/03").Return(nil, nil)
-		mockEsaClient.EXPECT().CreatePost(expectedText).Return(mockPost, nil)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, expectedText, "", fixedTime).Return(mockPost, nil)
 
 		// リクエスト作成
 		req := &TimesEsaPostRequest{
@@ -149,6 +222,216 @@ func TestSubmitDailyReport(t *testing.T) {
 		assert.True(t, result.Success)
 	})
 
+	t.Run("チームごとのデバウンステスト", func(t *testing.T) {
+		// 各テストケース前にdebounceをリセット
+		resetDebounce()
+
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト用データ
+		testText := "チームごとの投稿内容"
+		mockPost := &EsaPost{
+			Number: 123,
+			Name:   "テスト日報",
+			BodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> チームごとの投稿内容\n\n---",
+		}
+
+		// モックの振る舞いを設定（チームごとに1回ずつ投稿される）
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil).Times(2)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil).Times(2)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, testText, "", fixedTime).Return(mockPost, nil).Times(2)
+
+		// 別のチームへの同じ内容の投稿はデバウンスしない
+		for _, team := range []string{"work", "personal"} {
+			req := &TimesEsaPostRequest{
+				Text:            testText,
+				ConfirmedByUser: true,
+				Team:            team,
+			}
+			_, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+			require.NoError(t, err)
+		}
+
+		// 同じチームへの同じ内容の投稿はデバウンスする
+		req := &TimesEsaPostRequest{
+			Text:            testText,
+			ConfirmedByUser: true,
+			Team:            "work",
+		}
+		_, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "同じ内容の投稿が行われました")
+
+		// デバウンスの時間をやり直すまでの時間として返す
+		var toolErr *ToolError
+		require.ErrorAs(t, err, &toolErr)
+		assert.ErrorIs(t, err, ErrDebounced)
+		assert.True(t, toolErr.Retryable)
+		assert.Equal(t, DebounceDuration("work"), toolErr.RetryAfter)
+	})
+
+	t.Run("既存の日報との重複テスト", func(t *testing.T) {
+		// 各テストケース前にdebounceをリセット
+		resetDebounce()
+		SetRemoteDebounce(true)
+		defer SetRemoteDebounce(false)
+
+		// 他の端末から12:58と10:00に投稿された日報
+		existingPost := &EsaPost{
+			Number: 123,
+			Name:   "テスト日報",
+			BodyMd: "<a id=\"1258\" href=\"#1258\">12:58</a> ブラウザから投稿した内容\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 午前中に投稿した内容\n\n---",
+		}
+
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+
+		// デバウンスの時間内のエントリと同じ内容は拒否する
+		req := &TimesEsaPostRequest{
+			Text:            "ブラウザから投稿した内容",
+			ConfirmedByUser: true,
+		}
+		_, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "同じ内容のエントリが日報にあります（12:58 #1258）")
+		assert.ErrorIs(t, err, ErrDebounced)
+
+		// デバウンスの時間より前のエントリと同じ内容は投稿する
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, existingPost, "午前中に投稿した内容", "", fixedTime).Return(existingPost, nil)
+		req = &TimesEsaPostRequest{
+			Text:            "午前中に投稿した内容",
+			ConfirmedByUser: true,
+		}
+		_, err = submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		assert.NoError(t, err)
+	})
+
+	t.Run("日付が変わる時刻より前は0時前のエントリと比較する", func(t *testing.T) {
+		resetDebounce()
+		SetRemoteDebounce(true)
+		defer SetRemoteDebounce(false)
+
+		// 前日の23:58に投稿したエントリがある日報に、00:01に投稿する
+		afterMidnight := time.Date(2025, 5, 4, 0, 1, 0, 0, time.Local)
+		existingPost := &EsaPost{
+			Number: 123,
+			BodyMd: "<a id=\"2358\" href=\"#2358\">23:58</a> 日付をまたいだ内容\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 午前中に投稿した内容\n\n---",
+		}
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		client := &boundedEsaClient{MockEsaClientInterface: mockEsaClient, boundary: DayBoundary{Location: time.Local, RolloverHour: 4}}
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, afterMidnight).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+
+		req := &TimesEsaPostRequest{Text: "日付をまたいだ内容", ConfirmedByUser: true}
+		_, err := submitDailyReportWithClock(context.TODO(), nil, req, client, afterMidnight)
+		assert.ErrorIs(t, err, ErrDebounced)
+
+		// 10:00のエントリは未来の時刻ではなく前日の時刻として扱う
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, existingPost, "午前中に投稿した内容", "", afterMidnight).Return(existingPost, nil)
+		req = &TimesEsaPostRequest{Text: "午前中に投稿した内容", ConfirmedByUser: true}
+		_, err = submitDailyReportWithClock(context.TODO(), nil, req, client, afterMidnight)
+		assert.NoError(t, err)
+	})
+
+	t.Run("日付と時刻を指定して過去の日報に投稿するテスト", func(t *testing.T) {
+		resetDebounce()
+
+		// 前日の日報に、その日の18:30のエントリとして挿入する
+		postedAt := time.Date(2025, 5, 2, 18, 30, 0, 0, time.Local)
+		existingPost := &EsaPost{Number: 122, Category: "日報/2025/05/02"}
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, postedAt).Return("日報/2025/05/02", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/02").Return(existingPost, nil)
+		mockEsaClient.EXPECT().InsertEntry(mock.Anything, existingPost, "書き忘れた作業", "", postedAt).Return(existingPost, nil)
+
+		req := &TimesEsaPostRequest{Text: "書き忘れた作業", ConfirmedByUser: true, Date: "2025-05-02", Time: "18:30"}
+		result, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		require.NoError(t, err)
+		assert.Equal(t, 122, result.Post.Number)
+	})
+
+	t.Run("時刻だけを指定して今日の日報の時刻順の位置に投稿するテスト", func(t *testing.T) {
+		resetDebounce()
+
+		postedAt := time.Date(2025, 5, 3, 9, 15, 0, 0, time.Local)
+
+		// 日報がなければ指定した時刻で作成する
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, postedAt).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "朝の作業", "", postedAt).Return(&EsaPost{Number: 123}, nil)
+
+		req := &TimesEsaPostRequest{Text: "朝の作業", ConfirmedByUser: true, Time: "09:15"}
+		_, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		assert.NoError(t, err)
+	})
+
+	t.Run("見出しを指定して投稿するテスト", func(t *testing.T) {
+		resetDebounce()
+
+		existingPost := &EsaPost{Number: 123, BodyMd: "## やったこと\n\n## 明日やること\n"}
+		updatedPost := &EsaPost{Number: 123, BodyMd: "## やったこと\n\n## 明日やること\n\n<a id=\"1300\" href=\"#1300\">13:00</a> 資料を見直す\n\n---"}
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, existingPost, "資料を見直す", "明日やること", fixedTime).Return(updatedPost, nil)
+
+		req := &TimesEsaPostRequest{Text: "資料を見直す", ConfirmedByUser: true, Section: "明日やること"}
+		result, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		require.NoError(t, err)
+		assert.Equal(t, "日報を投稿しました", result.Message)
+	})
+
+	t.Run("指定した見出しがない場合はその旨を伝えるテスト", func(t *testing.T) {
+		resetDebounce()
+
+		existingPost := &EsaPost{Number: 123, BodyMd: "<a id=\"1000\" href=\"#1000\">10:00</a> 既存の内容\n\n---"}
+		updatedPost := &EsaPost{Number: 123, BodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 気づいたこと\n\n---\n\n" + existingPost.BodyMd}
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+		mockEsaClient.EXPECT().UpdatePost(mock.Anything, existingPost, "気づいたこと", "気づき", fixedTime).Return(updatedPost, nil)
+
+		req := &TimesEsaPostRequest{Text: "気づいたこと", ConfirmedByUser: true, Section: "気づき"}
+		result, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Contains(t, result.Message, "見出し「気づき」が見つからなかった")
+	})
+
+	t.Run("日付と時刻の指定が不正な場合のエラーテスト", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			date          string
+			time          string
+			expectedError string
+		}{
+			{name: "未来の日付", date: "2025-05-04", expectedError: "未来の日付（2025-05-04）"},
+			{name: "過去の日付で時刻を省略", date: "2025-05-02", expectedError: "timeでエントリの時刻も指定してください"},
+			{name: "時刻の形式が不正", time: "9時", expectedError: "HH:MM形式"},
+			{name: "日付の形式が不正", date: "2025/05/02", time: "09:00", expectedError: "YYYY-MM-DD形式"},
+			{name: "未来の時刻", time: "13:01", expectedError: "未来の時刻（2025-05-03 13:01）"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resetDebounce()
+				mockEsaClient := NewMockEsaClientInterface(t)
+
+				req := &TimesEsaPostRequest{Text: "テスト", ConfirmedByUser: true, Date: tt.date, Time: tt.time}
+				_, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+				assert.ErrorIs(t, err, ErrInvalidParams)
+				assert.ErrorContains(t, err, tt.expectedError)
+			})
+		}
+	})
+
 	t.Run("confirmed_by_user=falseの場合のエラーテスト", func(t *testing.T) {
 		// 各テストケース前にdebounceをリセット
 		resetDebounce()
@@ -169,6 +452,7 @@ func TestSubmitDailyReport(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "投稿前にユーザーによる内容の確認が必要です")
 		assert.Contains(t, err.Error(), "confirmed_by_user=trueを設定してください")
+		assert.ErrorIs(t, err, ErrNotConfirmed)
 	})
 
 	t.Run("空文字テスト", func(t *testing.T) {
@@ -192,3 +476,553 @@ func TestSubmitDailyReport(t *testing.T) {
 		assert.Contains(t, err.Error(), "empty")
 	})
 }
+
+func TestParsePostTime(t *testing.T) {
+	boundary := DayBoundary{Location: time.Local, RolloverHour: 4}
+
+	// 5/4 02:00（日付が変わる時刻より前なので5/3の日報の時間）
+	now := time.Date(2025, 5, 4, 2, 0, 0, 0, time.Local)
+
+	// 時刻だけを指定した場合は今日（5/3）の日報の中の時刻
+	postedAt, err := parsePostTime("", "23:10", now, boundary)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 5, 3, 23, 10, 0, 0, time.Local), postedAt)
+
+	postedAt, err = parsePostTime("", "01:30", now, boundary)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 5, 4, 1, 30, 0, 0, time.Local), postedAt)
+
+	// 日付を指定した場合は、日付が変わる時刻より前の時刻は翌日の時刻
+	postedAt, err = parsePostTime("2025-05-01", "03:00", now, boundary)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 5, 2, 3, 0, 0, 0, time.Local), postedAt)
+
+	// 指定がなければ現在時刻
+	postedAt, err = parsePostTime("", "", now, boundary)
+	require.NoError(t, err)
+	assert.Equal(t, now, postedAt)
+
+	// 過去の日付では時刻を省略できない
+	_, err = parsePostTime("2025-05-01", "", now, boundary)
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	// 今日の日付なら現在時刻
+	postedAt, err = parsePostTime("2025-05-03", "", now, boundary)
+	require.NoError(t, err)
+	assert.Equal(t, now, postedAt)
+
+	_, err = parsePostTime("2025-05-04", "", now, boundary)
+	assert.ErrorIs(t, err, ErrInvalidParams)
+}
+
+func TestReadDailyReport(t *testing.T) {
+	// テスト用の現在時刻を固定
+	fixedTime := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+
+	t.Run("今日の日報を読み取るテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト用データ
+		existingPost := &EsaPost{
+			Number: 123,
+			Name:   "テスト日報",
+			BodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> テスト追記内容\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 既存の内容\n\n---",
+		}
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+
+		// テスト対象の関数を実行
+		result, err := readDailyReportWithClock(context.TODO(), &TimesEsaReadRequest{}, mockEsaClient, fixedTime)
+
+		// 検証
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.True(t, result.Success)
+		assert.Equal(t, "2025-05-03", result.Date)
+		assert.Equal(t, "日報/2025/05/03", result.Category)
+		assert.Equal(t, []DailyReportEntry{
+			{Time: "13:00", AnchorID: "1300", Text: "テスト追記内容"},
+			{Time: "10:00", AnchorID: "1000", Text: "既存の内容"},
+		}, result.Entries)
+	})
+
+	t.Run("日付が変わる時刻より前は前日の日報を読み取る", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		// UTCで動いているプロセスでの、日本時間の5/4 01:30
+		now := time.Date(2025, 5, 3, 16, 30, 0, 0, time.UTC)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		client := &boundedEsaClient{MockEsaClientInterface: mockEsaClient, boundary: DayBoundary{Location: tokyo, RolloverHour: 4}}
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, now.In(tokyo)).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+
+		result, err := readDailyReportWithClock(context.TODO(), &TimesEsaReadRequest{}, client, now)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-05-03", result.Date)
+
+		// 日付を指定した場合はその日の日付が変わる時刻から
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, time.Date(2025, 4, 30, 4, 0, 0, 0, tokyo)).Return("日報/2025/04/30", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/04/30").Return(nil, nil)
+
+		result, err = readDailyReportWithClock(context.TODO(), &TimesEsaReadRequest{Date: "2025-04-30"}, client, now)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-04-30", result.Date)
+	})
+
+	t.Run("日付指定テスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, time.Date(2025, 4, 30, 0, 0, 0, 0, time.Local)).Return("日報/2025/04/30", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/04/30").Return(nil, nil)
+
+		// テスト対象の関数を実行
+		result, err := readDailyReportWithClock(context.TODO(), &TimesEsaReadRequest{Date: "2025-04-30"}, mockEsaClient, fixedTime)
+
+		// 日報が存在しない場合は空のエントリを返す
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.True(t, result.Success)
+		assert.Empty(t, result.Entries)
+		assert.Contains(t, result.Message, "日報はまだありません")
+	})
+
+	t.Run("不正な日付形式のエラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト対象の関数を実行
+		_, err := readDailyReportWithClock(context.TODO(), &TimesEsaReadRequest{Date: "2025/04/30"}, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "YYYY-MM-DD")
+	})
+
+	t.Run("検索エラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(nil, errors.New("API接続エラー"))
+
+		// テスト対象の関数を実行
+		_, err := readDailyReportWithClock(context.TODO(), &TimesEsaReadRequest{}, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "投稿の検索に失敗")
+	})
+}
+
+func TestEditDailyReportEntry(t *testing.T) {
+	// テスト用の現在時刻を固定
+	fixedTime := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+
+	existingPost := &EsaPost{
+		Number: 123,
+		Name:   "テスト日報",
+		BodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 誤字のある内容\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 既存の内容\n\n---",
+	}
+
+	// EditPostに渡された書き換えを既存の本文に適用するモック
+	applyEdit := func(ctx context.Context, post *EsaPost, edit func(string) (string, error)) (*EsaPost, error) {
+		bodyMd, err := edit(post.BodyMd)
+		if err != nil {
+			return nil, err
+		}
+		return &EsaPost{Number: post.Number, BodyMd: bodyMd}, nil
+	}
+
+	t.Run("アンカーIDで指定したエントリを編集するテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+		mockEsaClient.EXPECT().EditPost(mock.Anything, existingPost, mock.Anything).RunAndReturn(applyEdit)
+
+		// テスト対象の関数を実行
+		req := &TimesEsaEditEntryRequest{
+			AnchorID:        "1300",
+			Text:            "#times-esa 修正した内容",
+			ConfirmedByUser: true,
+		}
+		result, err := editDailyReportEntryWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		// 検証
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, DailyReportEntry{Time: "13:00", AnchorID: "1300", Text: "修正した内容"}, result.Entry)
+		assert.Equal(t, "<a id=\"1300\" href=\"#1300\">13:00</a> 修正した内容\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 既存の内容\n\n---", result.Post.BodyMd)
+	})
+
+	t.Run("日報が存在しない場合のエラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, time.Date(2025, 5, 2, 0, 0, 0, 0, time.Local)).Return("日報/2025/05/02", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/02").Return(nil, nil)
+
+		// テスト対象の関数を実行
+		req := &TimesEsaEditEntryRequest{
+			Date:            "2025-05-02",
+			Index:           1,
+			Text:            "修正した内容",
+			ConfirmedByUser: true,
+		}
+		_, err := editDailyReportEntryWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "2025-05-02の日報はまだありません")
+	})
+
+	t.Run("存在しないエントリのエラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+
+		// テスト対象の関数を実行
+		req := &TimesEsaEditEntryRequest{
+			AnchorID:        "2359",
+			Text:            "修正した内容",
+			ConfirmedByUser: true,
+		}
+		_, err := editDailyReportEntryWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.True(t, errors.Is(err, errEntryNotFound))
+	})
+
+	t.Run("エントリ未指定のエラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト対象の関数を実行
+		req := &TimesEsaEditEntryRequest{
+			Text:            "修正した内容",
+			ConfirmedByUser: true,
+		}
+		_, err := editDailyReportEntryWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "anchor_idまたはindexを指定してください")
+	})
+
+	t.Run("confirmed_by_user=falseの場合のエラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト対象の関数を実行
+		req := &TimesEsaEditEntryRequest{
+			AnchorID: "1300",
+			Text:     "修正した内容",
+		}
+		_, err := editDailyReportEntryWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "confirmed_by_user=true")
+	})
+}
+
+func TestDeleteDailyReportEntry(t *testing.T) {
+	// テスト用の現在時刻を固定
+	fixedTime := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+
+	t.Run("番号で指定したエントリを削除するテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト用データ
+		existingPost := &EsaPost{
+			Number: 123,
+			Name:   "テスト日報",
+			BodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 取り消す内容\n\n---",
+		}
+
+		// モックの振る舞いを設定
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(existingPost, nil)
+		mockEsaClient.EXPECT().EditPost(mock.Anything, existingPost, mock.Anything).RunAndReturn(func(ctx context.Context, post *EsaPost, edit func(string) (string, error)) (*EsaPost, error) {
+			bodyMd, err := edit(post.BodyMd)
+			require.NoError(t, err)
+			assert.Equal(t, "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---", bodyMd)
+			return &EsaPost{Number: post.Number, BodyMd: bodyMd}, nil
+		})
+
+		// テスト対象の関数を実行
+		req := &TimesEsaDeleteEntryRequest{
+			Index:           2,
+			ConfirmedByUser: true,
+		}
+		result, err := deleteDailyReportEntryWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		// 検証
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, DailyReportEntry{Time: "10:00", AnchorID: "1000", Text: "取り消す内容"}, result.Entry)
+		assert.Contains(t, result.Message, "10:00 (#1000) のエントリを削除しました")
+	})
+
+	t.Run("confirmed_by_user=falseの場合のエラーテスト", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		// テスト対象の関数を実行
+		_, err := deleteDailyReportEntryWithClock(context.TODO(), &TimesEsaDeleteEntryRequest{AnchorID: "1000"}, mockEsaClient, fixedTime)
+
+		// エラーが返ることを検証
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "confirmed_by_user=true")
+	})
+}
+
+func TestDuplicateDailyReports(t *testing.T) {
+	// テスト用の現在時刻を固定
+	fixedTime := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+	posts := []EsaPost{
+		{Number: 10, Name: "日報", Category: "日報/2025/05/03", BodyMd: "1件目"},
+		{Number: 12, Name: "日報", Category: "日報/2025/05/03", BodyMd: "2件目"},
+		{Number: 15, Name: "日報", Category: "日報/2025/05/03", BodyMd: "3件目"},
+	}
+
+	t.Run("重複した日報を一覧する", func(t *testing.T) {
+		// モックの作成
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostsByCategory(mock.Anything, "日報/2025/05/03").Return(posts, nil)
+
+		result, err := duplicateDailyReportsWithClock(context.TODO(), &TimesEsaDuplicatesRequest{}, mockEsaClient, fixedTime)
+
+		// 一覧するだけでまとめない
+		require.NoError(t, err)
+		require.Len(t, result.Posts, 3)
+		assert.Equal(t, 10, result.Posts[0].Number)
+		assert.Equal(t, "1件目", result.Posts[0].Excerpt)
+		assert.Nil(t, result.Post)
+		assert.Contains(t, result.Message, "2025-05-03の日報が3件あります（#10, #12, #15）")
+	})
+
+	t.Run("重複がない場合", func(t *testing.T) {
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostsByCategory(mock.Anything, "日報/2025/05/03").Return(posts[:1], nil)
+
+		// 重複がなければrepair=trueでも何もしない
+		result, err := duplicateDailyReportsWithClock(context.TODO(), &TimesEsaDuplicatesRequest{Repair: true, ConfirmedByUser: true}, mockEsaClient, fixedTime)
+		require.NoError(t, err)
+		assert.Contains(t, result.Message, "重複はありません")
+	})
+
+	t.Run("最も新しい日報にまとめる", func(t *testing.T) {
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().SearchPostsByCategory(mock.Anything, "日報/2025/05/03").Return(posts, nil)
+		mockEsaClient.EXPECT().MergePosts(mock.Anything, &posts[2], posts[:2]).Return(&EsaPost{Number: 15, BodyMd: "まとめた内容"}, nil)
+
+		req := &TimesEsaDuplicatesRequest{Repair: true, Keep: "newest", ConfirmedByUser: true}
+		result, err := duplicateDailyReportsWithClock(context.TODO(), req, mockEsaClient, fixedTime)
+
+		require.NoError(t, err)
+		assert.Equal(t, 15, result.Post.Number)
+		assert.Equal(t, []int{10, 12}, result.Archived)
+		assert.Contains(t, result.Message, "#15にまとめ、#10, #12をアーカイブしました")
+	})
+
+	t.Run("confirmed_by_user=falseの場合のエラーテスト", func(t *testing.T) {
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		_, err := duplicateDailyReportsWithClock(context.TODO(), &TimesEsaDuplicatesRequest{Repair: true}, mockEsaClient, fixedTime)
+		assert.ErrorIs(t, err, ErrNotConfirmed)
+	})
+
+	t.Run("keepが不正な場合のエラーテスト", func(t *testing.T) {
+		mockEsaClient := NewMockEsaClientInterface(t)
+
+		_, err := duplicateDailyReportsWithClock(context.TODO(), &TimesEsaDuplicatesRequest{Keep: "merge"}, mockEsaClient, fixedTime)
+		assert.ErrorIs(t, err, ErrInvalidParams)
+	})
+}
+
+func TestBuildSearchOptions(t *testing.T) {
+	wip := false
+	starred := true
+
+	tests := []struct {
+		name          string
+		params        EsaSearchRequest
+		expectedQuery string
+		expectedSort  string
+		expectedOrder string
+		expectedPage  int
+		expectedPer   int
+	}{
+		{
+			name:          "オプションなし",
+			params:        EsaSearchRequest{},
+			expectedQuery: "",
+			expectedSort:  "updated",
+			expectedOrder: "desc",
+			expectedPage:  1,
+			expectedPer:   20,
+		},
+		{
+			name: "全オプション指定",
+			params: EsaSearchRequest{
+				Keywords:       []string{"MCP", "サーバー"},
+				Tags:           []string{"golang"},
+				User:           "test_user",
+				CategoryPrefix: "日報/2025",
+				DateField:      "updated",
+				DateFrom:       "2025-01-01",
+				DateTo:         "2025-01-31",
+				WIP:            &wip,
+				Starred:        &starred,
+				Sort:           "created",
+				Order:          "asc",
+				Page:           2,
+				PerPage:        50,
+			},
+			expectedQuery: "in:日報/2025 MCP サーバー tag:golang user:test_user updated:>2025-01-01 updated:<2025-01-31 wip:false starred:true",
+			expectedSort:  "created",
+			expectedOrder: "asc",
+			expectedPage:  2,
+			expectedPer:   50,
+		},
+		{
+			name: "日付範囲のデフォルトはcreated",
+			params: EsaSearchRequest{
+				DateFrom: "2025-01-01",
+			},
+			expectedQuery: "created:>2025-01-01",
+			expectedSort:  "updated",
+			expectedOrder: "desc",
+			expectedPage:  1,
+			expectedPer:   20,
+		},
+		{
+			name: "per_pageは100に丸める",
+			params: EsaSearchRequest{
+				PerPage: 500,
+				Order:   "asc",
+			},
+			expectedQuery: "",
+			expectedSort:  "updated",
+			expectedOrder: "asc",
+			expectedPage:  1,
+			expectedPer:   100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := buildSearchOptions(&tt.params)
+			require.NoError(t, err)
+
+			config := &searchConfig{
+				page:    1,
+				perPage: 20,
+				sort:    "updated",
+				order:   "desc",
+			}
+			for _, opt := range options {
+				opt(config)
+			}
+
+			actualQuery := strings.TrimSpace(config.categoryQuery + " " + config.query)
+			assert.Equal(t, tt.expectedQuery, actualQuery)
+			assert.Equal(t, tt.expectedSort, config.sort)
+			assert.Equal(t, tt.expectedOrder, config.order)
+			assert.Equal(t, tt.expectedPage, config.page)
+			assert.Equal(t, tt.expectedPer, config.perPage)
+		})
+	}
+
+	t.Run("不正な日付形式のエラー", func(t *testing.T) {
+		_, err := buildSearchOptions(&EsaSearchRequest{DateTo: "2025/01/31"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "date_to")
+	})
+
+	t.Run("不正なdate_fieldのエラー", func(t *testing.T) {
+		_, err := buildSearchOptions(&EsaSearchRequest{DateField: "published", DateFrom: "2025-01-01"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "date_field")
+	})
+}
+
+func TestSearchPosts(t *testing.T) {
+	// モックHTTPクライアントの作成
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+
+	longBody := strings.Repeat("あ", searchExcerptLength+10)
+	mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Query().Get("q") == "tag:golang"
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body: io.NopCloser(strings.NewReader(`{
+			"posts": [{
+				"number": 123,
+				"name": "設計メモ",
+				"category": "dev/docs",
+				"tags": ["golang"],
+				"body_md": "` + longBody + `"
+			}],
+			"total_count": 1
+		}`)),
+	}, nil)
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{
+		TeamName:    "test-team",
+		AccessToken: "test-token",
+	})
+
+	result, err := searchPosts(context.TODO(), &EsaSearchRequest{Tags: []string{"golang"}}, client)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, result.TotalCount)
+	require.Len(t, result.Posts, 1)
+
+	post := result.Posts[0]
+	assert.Equal(t, 123, post.Number)
+	assert.Equal(t, "設計メモ", post.Name)
+	assert.Equal(t, "dev/docs", post.Category)
+	assert.Equal(t, []string{"golang"}, post.Tags)
+	assert.Equal(t, strings.Repeat("あ", searchExcerptLength)+"…", post.Excerpt)
+}
+
+// rateLimitedEsaClient はレート制限の状況を報告するモッククライアント
+type rateLimitedEsaClient struct {
+	*MockEsaClientInterface
+	rateLimit RateLimit
+}
+
+func (c *rateLimitedEsaClient) RateLimit() (RateLimit, bool) {
+	return c.rateLimit, true
+}
+
+// boundedEsaClient は日報の1日の区切りを報告するモッククライアント
+type boundedEsaClient struct {
+	*MockEsaClientInterface
+	boundary DayBoundary
+}
+
+func (c *boundedEsaClient) DayBoundary() DayBoundary {
+	return c.boundary
+}
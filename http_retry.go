@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultMaxRetries は最初のリクエストに加えて再試行する最大回数
+	defaultMaxRetries = 3
+
+	// defaultRetryBaseDelay は指数バックオフの初回待ち時間
+	defaultRetryBaseDelay = 500 * time.Millisecond
+
+	// defaultRetryMaxDelay は指数バックオフの待ち時間の上限
+	defaultRetryMaxDelay = 8 * time.Second
+
+	// defaultMaxRateLimitWait はレート制限の解除を待つ時間の上限（これを超える場合は待たずにエラーを返す）
+	defaultMaxRateLimitWait = 60 * time.Second
+
+	// rateLimitWarningThreshold はレスポンスで警告を出す残りリクエスト数
+	rateLimitWarningThreshold = 10
+)
+
+// RateLimitReporter は直近のレスポンスから得たレート制限の状況を報告するインターフェース
+type RateLimitReporter interface {
+	RateLimit() (RateLimit, bool)
+}
+
+// retryHTTPClient は一時的なエラーに対して指数バックオフで再試行するHTTPClientInterfaceのデコレーター
+type retryHTTPClient struct {
+	next             HTTPClientInterface
+	clock            Clock
+	sleep            func(ctx context.Context, d time.Duration) error
+	maxRetries       int
+	baseDelay        time.Duration
+	maxDelay         time.Duration
+	maxRateLimitWait time.Duration
+
+	mu        sync.Mutex
+	rateLimit *RateLimit
+}
+
+// NewRetryHTTPClient は429や5xx、一時的なネットワークエラーを再試行するHTTPClientInterfaceを返す
+func NewRetryHTTPClient(next HTTPClientInterface) HTTPClientInterface {
+	return &retryHTTPClient{
+		next:             next,
+		clock:            systemClock{},
+		sleep:            sleepContext,
+		maxRetries:       defaultMaxRetries,
+		baseDelay:        defaultRetryBaseDelay,
+		maxDelay:         defaultRetryMaxDelay,
+		maxRateLimitWait: defaultMaxRateLimitWait,
+	}
+}
+
+// Do はリクエストを実行し、再試行できる失敗の場合は待ってからやり直す
+func (c *retryHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.next.Do(attemptReq)
+		if resp != nil {
+			c.recordRateLimit(resp.Header)
+		}
+
+		wait, retry := c.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		// 再試行する場合は前回のレスポンスを読み捨てる
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := c.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// RateLimit は直近のレスポンスから得たレート制限の状況を返す
+func (c *retryHTTPClient) RateLimit() (RateLimit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rateLimit == nil {
+		return RateLimit{}, false
+	}
+	return *c.rateLimit, true
+}
+
+// retryDelay は再試行するかどうかと、再試行までの待ち時間を決める
+func (c *retryHTTPClient) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.maxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+
+	if err != nil {
+		// 送信前に接続できなかった場合を除き、POSTはサーバーに届いた可能性があるので再試行しない
+		if !isTransientNetworkError(err) {
+			return 0, false
+		}
+		if !isIdempotentMethod(req.Method) && !errors.Is(err, syscall.ECONNREFUSED) {
+			return 0, false
+		}
+		return c.backoff(attempt), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// レート制限で拒否されたリクエストは処理されていないので、POSTも再試行できる
+		wait, ok := rateLimitWait(resp.Header, c.clock.Now())
+		if !ok {
+			return c.backoff(attempt), true
+		}
+		if wait > c.maxRateLimitWait {
+			return 0, false
+		}
+		return wait, true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !isIdempotentMethod(req.Method) {
+			return 0, false
+		}
+		return c.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// backoff は試行回数に応じた指数バックオフの待ち時間を返す（ジッター付き）
+func (c *retryHTTPClient) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << attempt
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	// 待ち時間の半分から全体までの間でばらつかせる
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// rateLimitWait はRetry-AfterまたはX-RateLimit-Resetヘッダーから、制限が解除されるまでの待ち時間を求める
+func rateLimitWait(header http.Header, now time.Time) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		wait := time.Unix(reset, 0).Sub(now)
+		return max(wait, 0), true
+	}
+	return 0, false
+}
+
+// recordRateLimit はレスポンスヘッダーからレート制限の状況を記録する
+func (c *retryHTTPClient) recordRateLimit(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	rateLimit := &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimit = rateLimit
+}
+
+// rewindRequest は再試行のためにリクエストボディを先頭から読めるようにしたリクエストを返す
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// isIdempotentMethod は同じリクエストを繰り返しても結果が変わらないメソッドかどうかを返す
+// PATCHは本文全体を置き換える用途でのみ使っているため冪等として扱う
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// isTransientNetworkError は再試行で回復する見込みのあるネットワークエラーかどうかを返す
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// sleepContext はcontextがキャンセルされるまで、または指定時間が経過するまで待つ
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
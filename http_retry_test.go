@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRetryHTTPClient は待ち時間を記録するだけで実際には待たないretryHTTPClientを返す
+func newTestRetryHTTPClient(next HTTPClientInterface, clock Clock) (*retryHTTPClient, *[]time.Duration) {
+	var sleeps []time.Duration
+	client := NewRetryHTTPClient(next).(*retryHTTPClient)
+	client.clock = clock
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return client, &sleeps
+}
+
+// statusResponse は指定したステータスとヘッダーのレスポンスを返す
+func statusResponse(statusCode int, headers map[string]string) *http.Response {
+	header := http.Header{}
+	for key, value := range headers {
+		header.Set(key, value)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+	}
+}
+
+func TestRetryHTTPClient_Do(t *testing.T) {
+	now := time.Date(2025, 5, 3, 13, 0, 0, 0, time.UTC)
+
+	t.Run("GETは5xxを再試行する", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusServiceUnavailable, nil), nil).Once()
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusBadGateway, nil), nil).Once()
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusOK, nil), nil).Once()
+
+		client, sleeps := newTestRetryHTTPClient(mockHTTPClient, systemClock{})
+		req, _ := http.NewRequest("GET", "https://api.esa.io/v1/teams/test-team/posts", nil)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// 指数バックオフ（ジッター付き）で待つ
+		require.Len(t, *sleeps, 2)
+		assert.GreaterOrEqual(t, (*sleeps)[0], defaultRetryBaseDelay/2)
+		assert.LessOrEqual(t, (*sleeps)[0], defaultRetryBaseDelay)
+		assert.GreaterOrEqual(t, (*sleeps)[1], defaultRetryBaseDelay)
+		assert.LessOrEqual(t, (*sleeps)[1], 2*defaultRetryBaseDelay)
+	})
+
+	t.Run("POSTは5xxを再試行しない", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusInternalServerError, nil), nil).Once()
+
+		client, sleeps := newTestRetryHTTPClient(mockHTTPClient, systemClock{})
+		req, _ := http.NewRequest("POST", "https://api.esa.io/v1/teams/test-team/posts", strings.NewReader(`{}`))
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Empty(t, *sleeps)
+	})
+
+	t.Run("429はX-RateLimit-Resetまで待ってPOSTも再試行する", func(t *testing.T) {
+		mockClock := NewMockClock(t)
+		mockClock.EXPECT().Now().Return(now)
+
+		reset := now.Add(30 * time.Second)
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusTooManyRequests, map[string]string{
+			"X-RateLimit-Limit":     "75",
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+		}), nil).Once()
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusCreated, nil), nil).Once()
+
+		client, sleeps := newTestRetryHTTPClient(mockHTTPClient, mockClock)
+		req, _ := http.NewRequest("POST", "https://api.esa.io/v1/teams/test-team/posts", strings.NewReader(`{}`))
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, []time.Duration{30 * time.Second}, *sleeps)
+	})
+
+	t.Run("429の解除まで長く待つ場合は再試行しない", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusTooManyRequests, map[string]string{
+			"Retry-After": "900",
+		}), nil).Once()
+
+		client, sleeps := newTestRetryHTTPClient(mockHTTPClient, systemClock{})
+		req, _ := http.NewRequest("GET", "https://api.esa.io/v1/teams/test-team/posts", nil)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Empty(t, *sleeps)
+	})
+
+	t.Run("再試行の上限に達したら最後のレスポンスを返す", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			return statusResponse(http.StatusServiceUnavailable, nil), nil
+		}).Times(defaultMaxRetries + 1)
+
+		client, sleeps := newTestRetryHTTPClient(mockHTTPClient, systemClock{})
+		req, _ := http.NewRequest("GET", "https://api.esa.io/v1/teams/test-team/posts", nil)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Len(t, *sleeps, defaultMaxRetries)
+	})
+
+	t.Run("ネットワークエラーの再試行", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			method      string
+			err         error
+			expectRetry bool
+		}{
+			{name: "GETの接続リセット", method: "GET", err: syscall.ECONNRESET, expectRetry: true},
+			{name: "POSTの接続リセット", method: "POST", err: syscall.ECONNRESET, expectRetry: false},
+			{name: "POSTの接続拒否", method: "POST", err: syscall.ECONNREFUSED, expectRetry: true},
+			{name: "GETの恒久的なエラー", method: "GET", err: errors.New("unsupported protocol scheme"), expectRetry: false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockHTTPClient := NewMockHTTPClientInterface(t)
+				mockHTTPClient.EXPECT().Do(mock.Anything).Return(nil, tt.err).Once()
+				if tt.expectRetry {
+					mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusOK, nil), nil).Once()
+				}
+
+				client, _ := newTestRetryHTTPClient(mockHTTPClient, systemClock{})
+				req, _ := http.NewRequest(tt.method, "https://api.esa.io/v1/teams/test-team/posts", strings.NewReader(`{}`))
+
+				_, err := client.Do(req)
+				if tt.expectRetry {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, tt.err)
+				}
+			})
+		}
+	})
+
+	t.Run("再試行時もリクエストボディを送り直す", func(t *testing.T) {
+		var bodies []string
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				return statusResponse(http.StatusBadGateway, nil), nil
+			}
+			return statusResponse(http.StatusOK, nil), nil
+		}).Times(2)
+
+		client, _ := newTestRetryHTTPClient(mockHTTPClient, systemClock{})
+		req, _ := http.NewRequest("PATCH", "https://api.esa.io/v1/teams/test-team/posts/1", strings.NewReader(`{"post":{}}`))
+
+		_, err := client.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{"post":{}}`, `{"post":{}}`}, bodies)
+	})
+
+	t.Run("待機中にcontextがキャンセルされたら中断する", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusServiceUnavailable, nil), nil).Once()
+
+		client := NewRetryHTTPClient(mockHTTPClient)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.esa.io/v1/teams/test-team/posts", nil)
+
+		_, err := client.Do(req)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRetryHTTPClient_RateLimit(t *testing.T) {
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(mock.Anything).Return(statusResponse(http.StatusOK, map[string]string{
+		"X-RateLimit-Limit":     "75",
+		"X-RateLimit-Remaining": "5",
+		"X-RateLimit-Reset":     "1746277200",
+	}), nil).Once()
+
+	httpClient := NewRetryHTTPClient(mockHTTPClient)
+	client := NewEsaClient(httpClient, EsaConfig{
+		TeamName:    "test-team",
+		AccessToken: "test-token",
+	})
+
+	// リクエスト前は不明
+	_, ok := client.RateLimit()
+	assert.False(t, ok)
+
+	_, err := client.Search(context.Background())
+	require.NoError(t, err)
+
+	rateLimit, ok := client.RateLimit()
+	require.True(t, ok)
+	assert.Equal(t, RateLimit{
+		Limit:     75,
+		Remaining: 5,
+		Reset:     time.Unix(1746277200, 0),
+	}, rateLimit)
+}
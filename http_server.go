@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// MCPクライアントとの通信方法（ServerConfig.Transportで指定する）
+	transportStdio = "stdio"
+	transportHTTP  = "http"
+
+	// defaultListenAddr はhttpの場合にデフォルトで待ち受けるアドレス（同じマシンからのみ接続できる）
+	defaultListenAddr = "127.0.0.1:8080"
+
+	// mcpHTTPPath はstreamable HTTPのMCPのエンドポイント
+	mcpHTTPPath = "/mcp"
+
+	// shutdownTimeout は終了時に処理中のリクエストを待つ最大の時間
+	shutdownTimeout = 10 * time.Second
+
+	// readHeaderTimeout はリクエストヘッダーの読み取りのタイムアウト
+	readHeaderTimeout = 10 * time.Second
+)
+
+// newHTTPHandler はstreamable HTTPでMCPサーバーを公開するハンドラーを返す
+// 複数のMCPクライアントが同じサーバーにセッションを作って接続できる
+func newHTTPHandler(server *mcp.Server, authToken string) http.Handler {
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, nil)
+
+	mux := http.NewServeMux()
+	mux.Handle(mcpHTTPPath, auth.RequireBearerToken(bearerTokenVerifier(authToken), nil)(handler))
+	return mux
+}
+
+// bearerTokenVerifier は設定したトークンと一致するBearerトークンだけを受け付けるTokenVerifierを返す
+func bearerTokenVerifier(authToken string) auth.TokenVerifier {
+	return func(ctx context.Context, token string, req *http.Request) (*auth.TokenInfo, error) {
+		if authToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(authToken)) != 1 {
+			return nil, fmt.Errorf("トークンが一致しません: %w", auth.ErrInvalidToken)
+		}
+		// 設定したトークンは失効しないが、RequireBearerTokenは有効期限を必須とする
+		return &auth.TokenInfo{Expiration: time.Now().Add(time.Hour)}, nil
+	}
+}
+
+// serveHTTP はlistenerでhandlerを公開し、ctxが終了したら処理中のリクエストを待ってから停止する
+func serveHTTP(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	// 終了時に、クライアントが切断するまで続くSSEの待ち受けを閉じる
+	streams, closeStreams := context.WithCancel(context.Background())
+	defer closeStreams()
+
+	server := &http.Server{
+		Handler:           closeStreamsOnShutdown(handler, streams),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	server.RegisterOnShutdown(closeStreams)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// 待っても終わらない接続が残っている場合は強制的に閉じる
+		server.Close()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("HTTPサーバーの停止に失敗: %w", err)
+		}
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// closeStreamsOnShutdown はshutdownが終了したらSSEの待ち受け（GETのリクエスト）を切断するハンドラーを返す
+// 処理中のツールの呼び出し（POSTのリクエスト）は切断せず、完了するまで待つ
+func closeStreamsOnShutdown(handler http.Handler, shutdown context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(shutdown, cancel)
+			defer stop()
+			r = r.WithContext(ctx)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
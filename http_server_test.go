@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bearerTransport はリクエストにBearerトークンを付けるRoundTripper
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// startTestHTTPServer はテスト用の設定でstreamable HTTPのMCPサーバーを起動し、エンドポイントのURLと停止する関数を返す
+func startTestHTTPServer(t *testing.T) (string, func() error) {
+	t.Helper()
+	config := DefaultConfig()
+	config.TeamName = "test-team"
+	config.AccessToken = "test-token"
+	config.DebounceStore = memoryDebounceStoreName
+	config.Outbox.Store = outboxDisabledName
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serveHTTP(ctx, listener, newHTTPHandler(newMCPServer(config), "secret"))
+	}()
+
+	stop := func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(shutdownTimeout):
+			t.Fatal("HTTPサーバーが停止しませんでした")
+			return nil
+		}
+	}
+	t.Cleanup(func() { cancel() })
+	return "http://" + listener.Addr().String() + mcpHTTPPath, stop
+}
+
+// connectHTTPClient はBearerトークンを付けてMCPクライアントを接続する
+func connectHTTPClient(t *testing.T, endpoint, token string) (*mcp.ClientSession, error) {
+	t.Helper()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	return client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Transport: bearerTransport{token: token}},
+		MaxRetries: -1,
+	}, nil)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	resetDebounce()
+	endpoint, stop := startTestHTTPServer(t)
+
+	t.Run("Bearerトークンがないリクエストは拒否する", func(t *testing.T) {
+		resp, err := http.Post(endpoint, "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		_, err = connectHTTPClient(t, endpoint, "wrong")
+		assert.Error(t, err)
+	})
+
+	t.Run("複数のクライアントから接続してツールを呼び出せる", func(t *testing.T) {
+		ctx := context.Background()
+		sessions := make([]*mcp.ClientSession, 2)
+		for i := range sessions {
+			session, err := connectHTTPClient(t, endpoint, "secret")
+			require.NoError(t, err)
+			defer session.Close()
+			sessions[i] = session
+		}
+		assert.NotEqual(t, sessions[0].ID(), sessions[1].ID())
+
+		tools, err := sessions[0].ListTools(ctx, nil)
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range tools.Tools {
+			names = append(names, tool.Name)
+		}
+		assert.Contains(t, names, "times-esa")
+		assert.Contains(t, names, "times-esa-read")
+
+		// esa.io APIを呼び出す前に止まる呼び出しで、エラーの結果が返ることを確認する
+		result, err := sessions[1].CallTool(ctx, &mcp.CallToolParams{
+			Name:      "times-esa",
+			Arguments: map[string]any{"text": "テスト", "confirmed_by_user": false},
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		data, err := json.Marshal(result.StructuredContent)
+		require.NoError(t, err)
+		var response ToolErrorResponse
+		require.NoError(t, json.Unmarshal(data, &response))
+		assert.Equal(t, "not_confirmed", response.Error.Code)
+	})
+
+	t.Run("接続中のクライアントがいても停止できる", func(t *testing.T) {
+		session, err := connectHTTPClient(t, endpoint, "secret")
+		require.NoError(t, err)
+		defer session.Close()
+
+		started := time.Now()
+		assert.NoError(t, stop())
+		assert.Less(t, time.Since(started), shutdownTimeout)
+
+		_, err = http.Post(endpoint, "application/json", strings.NewReader(`{}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// updateLockedFile はpathのファイルの内容をfnに渡し、fnが返した内容で置き換える（ファイルがなければ空の内容を渡す）
+// 読み込みから置き換えまでは別のロックファイル（path + ".lock"）で他のプロセスと排他する
+// 新しい内容は一時ファイルに書いてfsyncしてからrenameで置き換えるため、途中で終了しても元の内容か新しい内容のどちらかが残る
+// nameはエラーメッセージに使う保存する内容の名前
+func updateLockedFile(path, name string, fn func(data []byte) ([]byte, error)) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("%sのディレクトリの作成に失敗: %w", name, err)
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("%sのロックファイルを開けません: %w", name, err)
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("%sのファイルのロックに失敗: %w", name, err)
+	}
+	defer unlockFile(lock)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%sの読み込みに失敗: %w", name, err)
+	}
+
+	data, err = fn(data)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceFile(path, data); err != nil {
+		return fmt.Errorf("%sの保存に失敗: %w", name, err)
+	}
+	return nil
+}
+
+// replaceFile は同じディレクトリの一時ファイルにdataを書いてfsyncし、pathをその一時ファイルで置き換える
+func replaceFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// 置き換えに成功した後は一時ファイルが存在しないため、削除のエラーは無視する
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// renameをディスクに反映する（ディレクトリのfsyncに対応していない環境では無視する）
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
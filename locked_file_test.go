@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLockedFile(t *testing.T) {
+	t.Run("ファイルがなければ空の内容から作成する", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "times-esa", "outbox.json")
+
+		err := updateLockedFile(path, "テスト", func(data []byte) ([]byte, error) {
+			assert.Empty(t, data)
+			return []byte(`["作成"]`), nil
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `["作成"]`, string(data))
+	})
+
+	t.Run("一時ファイルを残さずに置き換える", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "outbox.json")
+		require.NoError(t, os.WriteFile(path, []byte(`["元の内容"]`), 0o600))
+
+		err := updateLockedFile(path, "テスト", func(data []byte) ([]byte, error) {
+			assert.Equal(t, `["元の内容"]`, string(data))
+			return []byte(`["新しい内容"]`), nil
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `["新しい内容"]`, string(data))
+
+		// ロックは別のファイルで行い、一時ファイルは残らない
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.ElementsMatch(t, []string{"outbox.json", "outbox.json.lock"}, names)
+	})
+
+	t.Run("fnが失敗した場合は元の内容を残す", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "outbox.json")
+		require.NoError(t, os.WriteFile(path, []byte(`["元の内容"]`), 0o600))
+
+		failure := errors.New("変換できません")
+		err := updateLockedFile(path, "テスト", func(data []byte) ([]byte, error) {
+			return nil, failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `["元の内容"]`, string(data))
+	})
+}
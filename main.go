@@ -2,13 +2,78 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func main() {
+	// 設定ファイル・環境変数・コマンドライン引数から設定を読み込む
+	config, args, err := LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// SIGINT・SIGTERMを受け取ったら処理中のリクエストを待ってから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// サブコマンドが指定された場合はMCPサーバーを起動せずに実行する
+	if len(args) > 0 {
+		err := runCommand(ctx, newCommandEnv(config), args)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := runServer(ctx, config); err != nil {
+		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runServer は設定されたトランスポートでMCPサーバーを起動し、ctxが終了するまで待つ
+func runServer(ctx context.Context, config EsaConfig) error {
+	s := newMCPServer(config)
+
+	// 保留している投稿を定期的にやり直す
+	if outbox := currentOutbox(); outbox != nil {
+		factory := &DefaultHandlerFactory{Config: config}
+		go runOutboxFlusher(ctx, outbox, factory.CreateEsaClient, config.Outbox.FlushInterval)
+	}
+
+	if config.Server.Transport != transportHTTP {
+		return s.Run(ctx, &mcp.StdioTransport{})
+	}
+
+	listener, err := net.Listen("tcp", config.Server.Listen)
+	if err != nil {
+		return fmt.Errorf("%sで待ち受けできません: %w", config.Server.Listen, err)
+	}
+	fmt.Fprintf(os.Stderr, "Listening on http://%s%s\n", listener.Addr(), mcpHTTPPath)
+	return serveHTTP(ctx, listener, newHTTPHandler(s, config.Server.AuthToken))
+}
+
+// newMCPServer はツールを登録したMCPサーバーを作成する
+func newMCPServer(config EsaConfig) *mcp.Server {
+	factory := NewDefaultHandlerFactory(config)
+
 	s := mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "times-esa-mcp-server",
@@ -29,6 +94,19 @@ func main() {
 				Type:        "boolean",
 				Description: "ユーザーが投稿内容を確認したかどうか（true: 確認済みで投稿実行）",
 			},
+			"team": teamSchema(config, "投稿先"),
+			"date": {
+				Type:        "string",
+				Description: "書き忘れたエントリを過去の日報に書く場合の日付（YYYY-MM-DD形式、省略時は今日。過去の日付ではtimeも指定する）",
+			},
+			"time": {
+				Type:        "string",
+				Description: "エントリの時刻（HH:MM形式）。指定すると日報のエントリの時刻順の位置に挿入する",
+			},
+			"section": {
+				Type:        "string",
+				Description: "エントリを書く見出し（例: やったこと・明日やること・気づき）。body_templateの見出しの下に書き、見出しがなければ通常の位置に書く",
+			},
 		},
 		Required: []string{"text", "confirmed_by_user"},
 	}
@@ -39,9 +117,257 @@ func main() {
 		Description: "times-esaに日報を投稿します",
 		InputSchema: schema,
 	}
-	mcp.AddTool(s, tool, submitDailyReportHandler)
+	mcp.AddTool(s, tool, factory.submitDailyReportHandler)
+
+	// times-esa-readツールのスキーマ定義
+	readSchema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"date": {
+				Type:        "string",
+				Description: "読み取る日報の日付（YYYY-MM-DD形式、省略時は今日）",
+			},
+			"team": teamSchema(config, "読み取り対象"),
+		},
+	}
+
+	// ツールの登録
+	readTool := &mcp.Tool{
+		Name:        "times-esa-read",
+		Description: "times-esaの日報を読み取り、時刻ごとのエントリとして返します",
+		InputSchema: readSchema,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+	mcp.AddTool(s, readTool, factory.readDailyReportHandler)
+
+	// times-esa-editツールのスキーマ定義
+	editSchema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"date": {
+				Type:        "string",
+				Description: "編集する日報の日付（YYYY-MM-DD形式、省略時は今日）",
+			},
+			"anchor_id": {
+				Type:        "string",
+				Description: "編集するエントリのアンカーID（times-esa-readで返されるanchor_id。1234・1234-2・12:34の形式）",
+			},
+			"index": {
+				Type:        "integer",
+				Description: "編集するエントリの番号（アンカー付きのエントリを上から数えた1始まりの番号、anchor_idが重複する場合に指定）",
+			},
+			"text": {
+				Type:        "string",
+				Description: "置き換え後のテキスト内容",
+			},
+			"confirmed_by_user": {
+				Type:        "boolean",
+				Description: "ユーザーが編集内容を確認したかどうか（true: 確認済みで編集実行）",
+			},
+			"team": teamSchema(config, "編集対象"),
+		},
+		Required: []string{"text", "confirmed_by_user"},
+	}
+
+	// ツールの登録
+	editTool := &mcp.Tool{
+		Name:        "times-esa-edit",
+		Description: "times-esaの日報のエントリ1件の本文を、アンカーIDまたは番号で指定して置き換えます",
+		InputSchema: editSchema,
+	}
+	mcp.AddTool(s, editTool, factory.editDailyReportEntryHandler)
+
+	// times-esa-deleteツールのスキーマ定義
+	deleteSchema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"date": {
+				Type:        "string",
+				Description: "削除するエントリがある日報の日付（YYYY-MM-DD形式、省略時は今日）",
+			},
+			"anchor_id": {
+				Type:        "string",
+				Description: "削除するエントリのアンカーID（times-esa-readで返されるanchor_id。1234・1234-2・12:34の形式）",
+			},
+			"index": {
+				Type:        "integer",
+				Description: "削除するエントリの番号（アンカー付きのエントリを上から数えた1始まりの番号、anchor_idが重複する場合に指定）",
+			},
+			"confirmed_by_user": {
+				Type:        "boolean",
+				Description: "ユーザーが削除する内容を確認したかどうか（true: 確認済みで削除実行）",
+			},
+			"team": teamSchema(config, "削除対象"),
+		},
+		Required: []string{"confirmed_by_user"},
+	}
+
+	// ツールの登録
+	deleteTool := &mcp.Tool{
+		Name:        "times-esa-delete",
+		Description: "times-esaの日報のエントリ1件を、アンカーIDまたは番号で指定して削除します",
+		InputSchema: deleteSchema,
+	}
+	mcp.AddTool(s, deleteTool, factory.deleteDailyReportEntryHandler)
 
-	if err := s.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-		fmt.Printf("Server error: %v\n", err)
+	// esa-searchツールのスキーマ定義
+	searchSchema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"keywords": {
+				Type:        "array",
+				Items:       &jsonschema.Schema{Type: "string"},
+				Description: "本文・タイトルに含まれるキーワード（AND検索）",
+			},
+			"tags": {
+				Type:        "array",
+				Items:       &jsonschema.Schema{Type: "string"},
+				Description: "絞り込むタグ（AND検索）",
+			},
+			"user": {
+				Type:        "string",
+				Description: "投稿者のscreen_name",
+			},
+			"category_prefix": {
+				Type:        "string",
+				Description: "カテゴリーの前方一致（例: 日報/2025/05）",
+			},
+			"date_field": {
+				Type:        "string",
+				Enum:        []any{"created", "updated"},
+				Description: "日付範囲で絞り込む対象（省略時はcreated）",
+			},
+			"date_from": {
+				Type:        "string",
+				Description: "この日付より後の投稿に絞り込む（YYYY-MM-DD形式）",
+			},
+			"date_to": {
+				Type:        "string",
+				Description: "この日付より前の投稿に絞り込む（YYYY-MM-DD形式）",
+			},
+			"wip": {
+				Type:        "boolean",
+				Description: "WIP状態で絞り込む",
+			},
+			"starred": {
+				Type:        "boolean",
+				Description: "スター済みかどうかで絞り込む",
+			},
+			"sort": {
+				Type:        "string",
+				Enum:        []any{"updated", "created", "number", "stars", "watches", "comments", "best_match"},
+				Description: "並び順の基準（省略時はupdated）",
+			},
+			"order": {
+				Type:        "string",
+				Enum:        []any{"desc", "asc"},
+				Description: "昇順・降順（省略時はdesc）",
+			},
+			"page": {
+				Type:        "integer",
+				Description: "ページ番号（1始まり）",
+			},
+			"per_page": {
+				Type:        "integer",
+				Description: "1ページあたりの件数（最大100）",
+			},
+			"team": teamSchema(config, "検索対象"),
+		},
+	}
+
+	// ツールの登録
+	searchTool := &mcp.Tool{
+		Name:        "esa-search",
+		Description: "esa.ioの投稿をキーワード・タグ・カテゴリーなどで検索します",
+		InputSchema: searchSchema,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+	mcp.AddTool(s, searchTool, factory.searchPostsHandler)
+
+	// times-esa-duplicatesツールのスキーマ定義
+	duplicatesSchema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"date": {
+				Type:        "string",
+				Description: "重複を確認する日報の日付（YYYY-MM-DD形式、省略時は今日）",
+			},
+			"repair": {
+				Type:        "boolean",
+				Description: "重複した日報を1件にまとめるかどうか（false: 一覧のみ、true: まとめて残りをアーカイブ）",
+			},
+			"keep": {
+				Type:        "string",
+				Enum:        []any{duplicatePolicyOldest, duplicatePolicyNewest},
+				Description: "まとめる際に残す日報（oldest: 最も古い日報、newest: 最も新しい日報、省略時はoldest）",
+			},
+			"confirmed_by_user": {
+				Type:        "boolean",
+				Description: "ユーザーがまとめる日報を確認したかどうか（repair=trueの場合に必要）",
+			},
+			"team": teamSchema(config, "確認対象"),
+		},
+	}
+
+	// ツールの登録
+	duplicatesTool := &mcp.Tool{
+		Name:        "times-esa-duplicates",
+		Description: "同じ日の日報が複数存在しないかを確認し、指定があれば本文を1件にまとめて残りをアーカイブします",
+		InputSchema: duplicatesSchema,
+	}
+	mcp.AddTool(s, duplicatesTool, factory.duplicateDailyReportsHandler)
+
+	// times-esa-outboxツールのスキーマ定義
+	outboxSchema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"action": {
+				Type:        "string",
+				Enum:        []any{outboxActionList, outboxActionFlush, outboxActionDrop},
+				Description: "操作（list: 一覧、flush: 今すぐ投稿をやり直す、drop: idsで指定した投稿を取り消す、省略時はlist）",
+			},
+			"ids": {
+				Type:        "array",
+				Items:       &jsonschema.Schema{Type: "string"},
+				Description: "取り消す投稿のID（dropの場合に指定）",
+			},
+			"confirmed_by_user": {
+				Type:        "boolean",
+				Description: "ユーザーが取り消す投稿を確認したかどうか（dropの場合に必要）",
+			},
+		},
+	}
+
+	// ツールの登録
+	outboxTool := &mcp.Tool{
+		Name:        "times-esa-outbox",
+		Description: "esa.ioに接続できずに保留した投稿を一覧し、今すぐ投稿をやり直したり取り消したりします",
+		InputSchema: outboxSchema,
+	}
+	mcp.AddTool(s, outboxTool, factory.outboxHandler)
+
+	return s
+}
+
+// teamSchema はツールのteamパラメーターのスキーマを返す
+// プロファイルが設定されている場合は選択肢として列挙する
+func teamSchema(config EsaConfig, target string) *jsonschema.Schema {
+	schema := &jsonschema.Schema{
+		Type:        "string",
+		Description: fmt.Sprintf("%sのチーム（省略時はデフォルトのチーム）", target),
+	}
+	for _, name := range config.TeamNames() {
+		schema.Enum = append(schema.Enum, name)
+	}
+	// 共通の設定のチームもプロファイルと並べて指定できる
+	if len(schema.Enum) > 0 && config.TeamName != "" {
+		if _, ok := config.Teams[config.TeamName]; !ok {
+			schema.Enum = append(schema.Enum, config.TeamName)
+		}
 	}
+	return schema
 }
@@ -5,11 +5,228 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 )
 
+// NewMockClock creates a new instance of MockClock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockClock(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockClock {
+	mock := &MockClock{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockClock is an autogenerated mock type for the Clock type
+type MockClock struct {
+	mock.Mock
+}
+
+type MockClock_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockClock) EXPECT() *MockClock_Expecter {
+	return &MockClock_Expecter{mock: &_m.Mock}
+}
+
+// Now provides a mock function for the type MockClock
+func (_mock *MockClock) Now() time.Time {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Now")
+	}
+
+	var r0 time.Time
+	if returnFunc, ok := ret.Get(0).(func() time.Time); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+	return r0
+}
+
+// MockClock_Now_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Now'
+type MockClock_Now_Call struct {
+	*mock.Call
+}
+
+// Now is a helper method to define mock.On call
+func (_e *MockClock_Expecter) Now() *MockClock_Now_Call {
+	return &MockClock_Now_Call{Call: _e.mock.On("Now")}
+}
+
+func (_c *MockClock_Now_Call) Run(run func()) *MockClock_Now_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockClock_Now_Call) Return(timeVal time.Time) *MockClock_Now_Call {
+	_c.Call.Return(timeVal)
+	return _c
+}
+
+func (_c *MockClock_Now_Call) RunAndReturn(run func() time.Time) *MockClock_Now_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockDayBoundaryReporter creates a new instance of MockDayBoundaryReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDayBoundaryReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDayBoundaryReporter {
+	mock := &MockDayBoundaryReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockDayBoundaryReporter is an autogenerated mock type for the DayBoundaryReporter type
+type MockDayBoundaryReporter struct {
+	mock.Mock
+}
+
+type MockDayBoundaryReporter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDayBoundaryReporter) EXPECT() *MockDayBoundaryReporter_Expecter {
+	return &MockDayBoundaryReporter_Expecter{mock: &_m.Mock}
+}
+
+// DayBoundary provides a mock function for the type MockDayBoundaryReporter
+func (_mock *MockDayBoundaryReporter) DayBoundary() DayBoundary {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DayBoundary")
+	}
+
+	var r0 DayBoundary
+	if returnFunc, ok := ret.Get(0).(func() DayBoundary); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(DayBoundary)
+	}
+	return r0
+}
+
+// MockDayBoundaryReporter_DayBoundary_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DayBoundary'
+type MockDayBoundaryReporter_DayBoundary_Call struct {
+	*mock.Call
+}
+
+// DayBoundary is a helper method to define mock.On call
+func (_e *MockDayBoundaryReporter_Expecter) DayBoundary() *MockDayBoundaryReporter_DayBoundary_Call {
+	return &MockDayBoundaryReporter_DayBoundary_Call{Call: _e.mock.On("DayBoundary")}
+}
+
+func (_c *MockDayBoundaryReporter_DayBoundary_Call) Run(run func()) *MockDayBoundaryReporter_DayBoundary_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDayBoundaryReporter_DayBoundary_Call) Return(dayBoundary DayBoundary) *MockDayBoundaryReporter_DayBoundary_Call {
+	_c.Call.Return(dayBoundary)
+	return _c
+}
+
+func (_c *MockDayBoundaryReporter_DayBoundary_Call) RunAndReturn(run func() DayBoundary) *MockDayBoundaryReporter_DayBoundary_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockDebounceStore creates a new instance of MockDebounceStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDebounceStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDebounceStore {
+	mock := &MockDebounceStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockDebounceStore is an autogenerated mock type for the DebounceStore type
+type MockDebounceStore struct {
+	mock.Mock
+}
+
+type MockDebounceStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDebounceStore) EXPECT() *MockDebounceStore_Expecter {
+	return &MockDebounceStore_Expecter{mock: &_m.Mock}
+}
+
+// Update provides a mock function for the type MockDebounceStore
+func (_mock *MockDebounceStore) Update(team string, fn func(entries map[string]debounceEntry) error) error {
+	ret := _mock.Called(team, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, func(entries map[string]debounceEntry) error) error); ok {
+		r0 = returnFunc(team, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDebounceStore_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockDebounceStore_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - team
+//   - fn
+func (_e *MockDebounceStore_Expecter) Update(team interface{}, fn interface{}) *MockDebounceStore_Update_Call {
+	return &MockDebounceStore_Update_Call{Call: _e.mock.On("Update", team, fn)}
+}
+
+func (_c *MockDebounceStore_Update_Call) Run(run func(team string, fn func(entries map[string]debounceEntry) error)) *MockDebounceStore_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func(entries map[string]debounceEntry) error))
+	})
+	return _c
+}
+
+func (_c *MockDebounceStore_Update_Call) Return(err error) *MockDebounceStore_Update_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDebounceStore_Update_Call) RunAndReturn(run func(team string, fn func(entries map[string]debounceEntry) error) error) *MockDebounceStore_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockEsaClientInterface creates a new instance of MockEsaClientInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockEsaClientInterface(t interface {
@@ -29,247 +246,659 @@ type MockEsaClientInterface struct {
 	mock.Mock
 }
 
-type MockEsaClientInterface_Expecter struct {
-	mock *mock.Mock
+type MockEsaClientInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEsaClientInterface) EXPECT() *MockEsaClientInterface_Expecter {
+	return &MockEsaClientInterface_Expecter{mock: &_m.Mock}
+}
+
+// CreatePost provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) CreatePost(ctx context.Context, text string, section string, now time.Time) (*EsaPost, error) {
+	ret := _mock.Called(ctx, text, section, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePost")
+	}
+
+	var r0 *EsaPost
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (*EsaPost, error)); ok {
+		return returnFunc(ctx, text, section, now)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time) *EsaPost); ok {
+		r0 = returnFunc(ctx, text, section, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*EsaPost)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, text, section, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEsaClientInterface_CreatePost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreatePost'
+type MockEsaClientInterface_CreatePost_Call struct {
+	*mock.Call
+}
+
+// CreatePost is a helper method to define mock.On call
+//   - ctx
+//   - text
+//   - section
+//   - now
+func (_e *MockEsaClientInterface_Expecter) CreatePost(ctx interface{}, text interface{}, section interface{}, now interface{}) *MockEsaClientInterface_CreatePost_Call {
+	return &MockEsaClientInterface_CreatePost_Call{Call: _e.mock.On("CreatePost", ctx, text, section, now)}
+}
+
+func (_c *MockEsaClientInterface_CreatePost_Call) Run(run func(ctx context.Context, text string, section string, now time.Time)) *MockEsaClientInterface_CreatePost_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_CreatePost_Call) Return(r *EsaPost, err error) *MockEsaClientInterface_CreatePost_Call {
+	_c.Call.Return(r, err)
+	return _c
+}
+
+func (_c *MockEsaClientInterface_CreatePost_Call) RunAndReturn(run func(ctx context.Context, text string, section string, now time.Time) (*EsaPost, error)) *MockEsaClientInterface_CreatePost_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DailyReportCategory provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) DailyReportCategory(ctx context.Context, now time.Time) (string, error) {
+	ret := _mock.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DailyReportCategory")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) (string, error)); ok {
+		return returnFunc(ctx, now)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) string); ok {
+		r0 = returnFunc(ctx, now)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = returnFunc(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEsaClientInterface_DailyReportCategory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DailyReportCategory'
+type MockEsaClientInterface_DailyReportCategory_Call struct {
+	*mock.Call
+}
+
+// DailyReportCategory is a helper method to define mock.On call
+//   - ctx
+//   - now
+func (_e *MockEsaClientInterface_Expecter) DailyReportCategory(ctx interface{}, now interface{}) *MockEsaClientInterface_DailyReportCategory_Call {
+	return &MockEsaClientInterface_DailyReportCategory_Call{Call: _e.mock.On("DailyReportCategory", ctx, now)}
+}
+
+func (_c *MockEsaClientInterface_DailyReportCategory_Call) Run(run func(ctx context.Context, now time.Time)) *MockEsaClientInterface_DailyReportCategory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_DailyReportCategory_Call) Return(stringVal string, err error) *MockEsaClientInterface_DailyReportCategory_Call {
+	_c.Call.Return(stringVal, err)
+	return _c
+}
+
+func (_c *MockEsaClientInterface_DailyReportCategory_Call) RunAndReturn(run func(ctx context.Context, now time.Time) (string, error)) *MockEsaClientInterface_DailyReportCategory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EditPost provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) EditPost(ctx context.Context, existingPost *EsaPost, edit func(bodyMd string) (string, error)) (*EsaPost, error) {
+	ret := _mock.Called(ctx, existingPost, edit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EditPost")
+	}
+
+	var r0 *EsaPost
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, func(bodyMd string) (string, error)) (*EsaPost, error)); ok {
+		return returnFunc(ctx, existingPost, edit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, func(bodyMd string) (string, error)) *EsaPost); ok {
+		r0 = returnFunc(ctx, existingPost, edit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*EsaPost)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *EsaPost, func(bodyMd string) (string, error)) error); ok {
+		r1 = returnFunc(ctx, existingPost, edit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEsaClientInterface_EditPost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EditPost'
+type MockEsaClientInterface_EditPost_Call struct {
+	*mock.Call
+}
+
+// EditPost is a helper method to define mock.On call
+//   - ctx
+//   - existingPost
+//   - edit
+func (_e *MockEsaClientInterface_Expecter) EditPost(ctx interface{}, existingPost interface{}, edit interface{}) *MockEsaClientInterface_EditPost_Call {
+	return &MockEsaClientInterface_EditPost_Call{Call: _e.mock.On("EditPost", ctx, existingPost, edit)}
+}
+
+func (_c *MockEsaClientInterface_EditPost_Call) Run(run func(ctx context.Context, existingPost *EsaPost, edit func(bodyMd string) (string, error))) *MockEsaClientInterface_EditPost_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*EsaPost), args[2].(func(bodyMd string) (string, error)))
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_EditPost_Call) Return(esaPost *EsaPost, err error) *MockEsaClientInterface_EditPost_Call {
+	_c.Call.Return(esaPost, err)
+	return _c
+}
+
+func (_c *MockEsaClientInterface_EditPost_Call) RunAndReturn(run func(ctx context.Context, existingPost *EsaPost, edit func(bodyMd string) (string, error)) (*EsaPost, error)) *MockEsaClientInterface_EditPost_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPost provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) GetPost(ctx context.Context, number int) (*EsaPost, error) {
+	ret := _mock.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPost")
+	}
+
+	var r0 *EsaPost
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int) (*EsaPost, error)); ok {
+		return returnFunc(ctx, number)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int) *EsaPost); ok {
+		r0 = returnFunc(ctx, number)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*EsaPost)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = returnFunc(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEsaClientInterface_GetPost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPost'
+type MockEsaClientInterface_GetPost_Call struct {
+	*mock.Call
+}
+
+// GetPost is a helper method to define mock.On call
+//   - ctx
+//   - number
+func (_e *MockEsaClientInterface_Expecter) GetPost(ctx interface{}, number interface{}) *MockEsaClientInterface_GetPost_Call {
+	return &MockEsaClientInterface_GetPost_Call{Call: _e.mock.On("GetPost", ctx, number)}
+}
+
+func (_c *MockEsaClientInterface_GetPost_Call) Run(run func(ctx context.Context, number int)) *MockEsaClientInterface_GetPost_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_GetPost_Call) Return(esaPost *EsaPost, err error) *MockEsaClientInterface_GetPost_Call {
+	_c.Call.Return(esaPost, err)
+	return _c
+}
+
+func (_c *MockEsaClientInterface_GetPost_Call) RunAndReturn(run func(ctx context.Context, number int) (*EsaPost, error)) *MockEsaClientInterface_GetPost_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertEntry provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) InsertEntry(ctx context.Context, existingPost *EsaPost, text string, section string, at time.Time) (*EsaPost, error) {
+	ret := _mock.Called(ctx, existingPost, text, section, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertEntry")
+	}
+
+	var r0 *EsaPost
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, string, string, time.Time) (*EsaPost, error)); ok {
+		return returnFunc(ctx, existingPost, text, section, at)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, string, string, time.Time) *EsaPost); ok {
+		r0 = returnFunc(ctx, existingPost, text, section, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*EsaPost)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *EsaPost, string, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, existingPost, text, section, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEsaClientInterface_InsertEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertEntry'
+type MockEsaClientInterface_InsertEntry_Call struct {
+	*mock.Call
+}
+
+// InsertEntry is a helper method to define mock.On call
+//   - ctx
+//   - existingPost
+//   - text
+//   - section
+//   - at
+func (_e *MockEsaClientInterface_Expecter) InsertEntry(ctx interface{}, existingPost interface{}, text interface{}, section interface{}, at interface{}) *MockEsaClientInterface_InsertEntry_Call {
+	return &MockEsaClientInterface_InsertEntry_Call{Call: _e.mock.On("InsertEntry", ctx, existingPost, text, section, at)}
+}
+
+func (_c *MockEsaClientInterface_InsertEntry_Call) Run(run func(ctx context.Context, existingPost *EsaPost, text string, section string, at time.Time)) *MockEsaClientInterface_InsertEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*EsaPost), args[2].(string), args[3].(string), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_InsertEntry_Call) Return(r *EsaPost, err error) *MockEsaClientInterface_InsertEntry_Call {
+	_c.Call.Return(r, err)
+	return _c
+}
+
+func (_c *MockEsaClientInterface_InsertEntry_Call) RunAndReturn(run func(ctx context.Context, existingPost *EsaPost, text string, section string, at time.Time) (*EsaPost, error)) *MockEsaClientInterface_InsertEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Search provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) Search(ctx context.Context, options ...SearchOption) (*EsaSearchResult, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 *EsaSearchResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...SearchOption) (*EsaSearchResult, error)); ok {
+		return returnFunc(ctx, options...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...SearchOption) *EsaSearchResult); ok {
+		r0 = returnFunc(ctx, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*EsaSearchResult)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, ...SearchOption) error); ok {
+		r1 = returnFunc(ctx, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockEsaClientInterface_Search_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Search'
+type MockEsaClientInterface_Search_Call struct {
+	*mock.Call
+}
+
+// Search is a helper method to define mock.On call
+//   - ctx
+//   - options
+func (_e *MockEsaClientInterface_Expecter) Search(ctx interface{}, options ...interface{}) *MockEsaClientInterface_Search_Call {
+	return &MockEsaClientInterface_Search_Call{Call: _e.mock.On("Search",
+		append([]interface{}{ctx}, options...)...)}
+}
+
+func (_c *MockEsaClientInterface_Search_Call) Run(run func(ctx context.Context, options ...SearchOption)) *MockEsaClientInterface_Search_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SearchOption, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(SearchOption)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_Search_Call) Return(esaSearchResult *EsaSearchResult, err error) *MockEsaClientInterface_Search_Call {
+	_c.Call.Return(esaSearchResult, err)
+	return _c
 }
 
-func (_m *MockEsaClientInterface) EXPECT() *MockEsaClientInterface_Expecter {
-	return &MockEsaClientInterface_Expecter{mock: &_m.Mock}
+func (_c *MockEsaClientInterface_Search_Call) RunAndReturn(run func(ctx context.Context, options ...SearchOption) (*EsaSearchResult, error)) *MockEsaClientInterface_Search_Call {
+	_c.Call.Return(run)
+	return _c
 }
 
-// CreatePost provides a mock function for the type MockEsaClientInterface
-func (_mock *MockEsaClientInterface) CreatePost(text string) (*EsaPost, error) {
-	ret := _mock.Called(text)
+// MergePosts provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) MergePosts(ctx context.Context, primary *EsaPost, duplicates []EsaPost) (*EsaPost, error) {
+	ret := _mock.Called(ctx, primary, duplicates)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreatePost")
+		panic("no return value specified for MergePosts")
 	}
 
 	var r0 *EsaPost
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string) (*EsaPost, error)); ok {
-		return returnFunc(text)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, []EsaPost) (*EsaPost, error)); ok {
+		return returnFunc(ctx, primary, duplicates)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) *EsaPost); ok {
-		r0 = returnFunc(text)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, []EsaPost) *EsaPost); ok {
+		r0 = returnFunc(ctx, primary, duplicates)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*EsaPost)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
-		r1 = returnFunc(text)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *EsaPost, []EsaPost) error); ok {
+		r1 = returnFunc(ctx, primary, duplicates)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockEsaClientInterface_CreatePost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreatePost'
-type MockEsaClientInterface_CreatePost_Call struct {
+// MockEsaClientInterface_MergePosts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MergePosts'
+type MockEsaClientInterface_MergePosts_Call struct {
 	*mock.Call
 }
 
-// CreatePost is a helper method to define mock.On call
-//   - text
-func (_e *MockEsaClientInterface_Expecter) CreatePost(text interface{}) *MockEsaClientInterface_CreatePost_Call {
-	return &MockEsaClientInterface_CreatePost_Call{Call: _e.mock.On("CreatePost", text)}
+// MergePosts is a helper method to define mock.On call
+//   - ctx
+//   - primary
+//   - duplicates
+func (_e *MockEsaClientInterface_Expecter) MergePosts(ctx interface{}, primary interface{}, duplicates interface{}) *MockEsaClientInterface_MergePosts_Call {
+	return &MockEsaClientInterface_MergePosts_Call{Call: _e.mock.On("MergePosts", ctx, primary, duplicates)}
 }
 
-func (_c *MockEsaClientInterface_CreatePost_Call) Run(run func(text string)) *MockEsaClientInterface_CreatePost_Call {
+func (_c *MockEsaClientInterface_MergePosts_Call) Run(run func(ctx context.Context, primary *EsaPost, duplicates []EsaPost)) *MockEsaClientInterface_MergePosts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(*EsaPost), args[2].([]EsaPost))
 	})
 	return _c
 }
 
-func (_c *MockEsaClientInterface_CreatePost_Call) Return(esaPost *EsaPost, err error) *MockEsaClientInterface_CreatePost_Call {
-	_c.Call.Return(esaPost, err)
+func (_c *MockEsaClientInterface_MergePosts_Call) Return(post *EsaPost, err error) *MockEsaClientInterface_MergePosts_Call {
+	_c.Call.Return(post, err)
 	return _c
 }
 
-func (_c *MockEsaClientInterface_CreatePost_Call) RunAndReturn(run func(text string) (*EsaPost, error)) *MockEsaClientInterface_CreatePost_Call {
+func (_c *MockEsaClientInterface_MergePosts_Call) RunAndReturn(run func(ctx context.Context, primary *EsaPost, duplicates []EsaPost) (*EsaPost, error)) *MockEsaClientInterface_MergePosts_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SearchPostByCategory provides a mock function for the type MockEsaClientInterface
-func (_mock *MockEsaClientInterface) SearchPostByCategory(category string) (*EsaPost, error) {
-	ret := _mock.Called(category)
+// ResolveDailyReport provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) ResolveDailyReport(ctx context.Context, category string) (*EsaPost, error) {
+	ret := _mock.Called(ctx, category)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SearchPostByCategory")
+		panic("no return value specified for ResolveDailyReport")
 	}
 
 	var r0 *EsaPost
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string) (*EsaPost, error)); ok {
-		return returnFunc(category)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*EsaPost, error)); ok {
+		return returnFunc(ctx, category)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) *EsaPost); ok {
-		r0 = returnFunc(category)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *EsaPost); ok {
+		r0 = returnFunc(ctx, category)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*EsaPost)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
-		r1 = returnFunc(category)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, category)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockEsaClientInterface_SearchPostByCategory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchPostByCategory'
-type MockEsaClientInterface_SearchPostByCategory_Call struct {
+// MockEsaClientInterface_ResolveDailyReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResolveDailyReport'
+type MockEsaClientInterface_ResolveDailyReport_Call struct {
 	*mock.Call
 }
 
-// SearchPostByCategory is a helper method to define mock.On call
+// ResolveDailyReport is a helper method to define mock.On call
+//   - ctx
 //   - category
-func (_e *MockEsaClientInterface_Expecter) SearchPostByCategory(category interface{}) *MockEsaClientInterface_SearchPostByCategory_Call {
-	return &MockEsaClientInterface_SearchPostByCategory_Call{Call: _e.mock.On("SearchPostByCategory", category)}
+func (_e *MockEsaClientInterface_Expecter) ResolveDailyReport(ctx interface{}, category interface{}) *MockEsaClientInterface_ResolveDailyReport_Call {
+	return &MockEsaClientInterface_ResolveDailyReport_Call{Call: _e.mock.On("ResolveDailyReport", ctx, category)}
 }
 
-func (_c *MockEsaClientInterface_SearchPostByCategory_Call) Run(run func(category string)) *MockEsaClientInterface_SearchPostByCategory_Call {
+func (_c *MockEsaClientInterface_ResolveDailyReport_Call) Run(run func(ctx context.Context, category string)) *MockEsaClientInterface_ResolveDailyReport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockEsaClientInterface_SearchPostByCategory_Call) Return(esaPost *EsaPost, err error) *MockEsaClientInterface_SearchPostByCategory_Call {
-	_c.Call.Return(esaPost, err)
+func (_c *MockEsaClientInterface_ResolveDailyReport_Call) Return(r *EsaPost, err error) *MockEsaClientInterface_ResolveDailyReport_Call {
+	_c.Call.Return(r, err)
 	return _c
 }
 
-func (_c *MockEsaClientInterface_SearchPostByCategory_Call) RunAndReturn(run func(category string) (*EsaPost, error)) *MockEsaClientInterface_SearchPostByCategory_Call {
+func (_c *MockEsaClientInterface_ResolveDailyReport_Call) RunAndReturn(run func(ctx context.Context, category string) (*EsaPost, error)) *MockEsaClientInterface_ResolveDailyReport_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdatePost provides a mock function for the type MockEsaClientInterface
-func (_mock *MockEsaClientInterface) UpdatePost(existingPost *EsaPost, text string) (*EsaPost, error) {
-	ret := _mock.Called(existingPost, text)
+// SearchPostsByCategory provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) SearchPostsByCategory(ctx context.Context, category string) ([]EsaPost, error) {
+	ret := _mock.Called(ctx, category)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdatePost")
+		panic("no return value specified for SearchPostsByCategory")
 	}
 
-	var r0 *EsaPost
+	var r0 []EsaPost
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(*EsaPost, string) (*EsaPost, error)); ok {
-		return returnFunc(existingPost, text)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]EsaPost, error)); ok {
+		return returnFunc(ctx, category)
 	}
-	if returnFunc, ok := ret.Get(0).(func(*EsaPost, string) *EsaPost); ok {
-		r0 = returnFunc(existingPost, text)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []EsaPost); ok {
+		r0 = returnFunc(ctx, category)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*EsaPost)
+			r0 = ret.Get(0).([]EsaPost)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(*EsaPost, string) error); ok {
-		r1 = returnFunc(existingPost, text)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, category)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// Search provides a mock function for the type MockEsaClientInterface
-func (_mock *MockEsaClientInterface) Search(options ...SearchOption) (*EsaSearchResult, error) {
-	_va := make([]interface{}, len(options))
-	for _i := range options {
-		_va[_i] = options[_i]
-	}
-	var _ca []interface{}
-	_ca = append(_ca, _va...)
-	ret := _mock.Called(_ca...)
+// MockEsaClientInterface_SearchPostsByCategory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchPostsByCategory'
+type MockEsaClientInterface_SearchPostsByCategory_Call struct {
+	*mock.Call
+}
+
+// SearchPostsByCategory is a helper method to define mock.On call
+//   - ctx
+//   - category
+func (_e *MockEsaClientInterface_Expecter) SearchPostsByCategory(ctx interface{}, category interface{}) *MockEsaClientInterface_SearchPostsByCategory_Call {
+	return &MockEsaClientInterface_SearchPostsByCategory_Call{Call: _e.mock.On("SearchPostsByCategory", ctx, category)}
+}
+
+func (_c *MockEsaClientInterface_SearchPostsByCategory_Call) Run(run func(ctx context.Context, category string)) *MockEsaClientInterface_SearchPostsByCategory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockEsaClientInterface_SearchPostsByCategory_Call) Return(posts []EsaPost, err error) *MockEsaClientInterface_SearchPostsByCategory_Call {
+	_c.Call.Return(posts, err)
+	return _c
+}
+
+func (_c *MockEsaClientInterface_SearchPostsByCategory_Call) RunAndReturn(run func(ctx context.Context, category string) ([]EsaPost, error)) *MockEsaClientInterface_SearchPostsByCategory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SearchPostByCategory provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) SearchPostByCategory(ctx context.Context, category string) (*EsaPost, error) {
+	ret := _mock.Called(ctx, category)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Search")
+		panic("no return value specified for SearchPostByCategory")
 	}
 
-	var r0 *EsaSearchResult
+	var r0 *EsaPost
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(...SearchOption) (*EsaSearchResult, error)); ok {
-		return returnFunc(options...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*EsaPost, error)); ok {
+		return returnFunc(ctx, category)
 	}
-	if returnFunc, ok := ret.Get(0).(func(...SearchOption) *EsaSearchResult); ok {
-		r0 = returnFunc(options...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *EsaPost); ok {
+		r0 = returnFunc(ctx, category)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*EsaSearchResult)
+			r0 = ret.Get(0).(*EsaPost)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(...SearchOption) error); ok {
-		r1 = returnFunc(options...)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, category)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockEsaClientInterface_Search_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Search'
-type MockEsaClientInterface_Search_Call struct {
+// MockEsaClientInterface_SearchPostByCategory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchPostByCategory'
+type MockEsaClientInterface_SearchPostByCategory_Call struct {
 	*mock.Call
 }
 
-// Search is a helper method to define mock.On call
-//   - options
-func (_e *MockEsaClientInterface_Expecter) Search(options interface{}) *MockEsaClientInterface_Search_Call {
-	return &MockEsaClientInterface_Search_Call{Call: _e.mock.On("Search", options)}
+// SearchPostByCategory is a helper method to define mock.On call
+//   - ctx
+//   - category
+func (_e *MockEsaClientInterface_Expecter) SearchPostByCategory(ctx interface{}, category interface{}) *MockEsaClientInterface_SearchPostByCategory_Call {
+	return &MockEsaClientInterface_SearchPostByCategory_Call{Call: _e.mock.On("SearchPostByCategory", ctx, category)}
 }
 
-func (_c *MockEsaClientInterface_Search_Call) Run(run func(options ...SearchOption)) *MockEsaClientInterface_Search_Call {
+func (_c *MockEsaClientInterface_SearchPostByCategory_Call) Run(run func(ctx context.Context, category string)) *MockEsaClientInterface_SearchPostByCategory_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]SearchOption, len(args))
-		for i, a := range args {
-			if a != nil {
-				variadicArgs[i] = a.(SearchOption)
-			}
-		}
-		run(variadicArgs...)
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockEsaClientInterface_Search_Call) Return(result *EsaSearchResult, err error) *MockEsaClientInterface_Search_Call {
-	_c.Call.Return(result, err)
+func (_c *MockEsaClientInterface_SearchPostByCategory_Call) Return(esaPost *EsaPost, err error) *MockEsaClientInterface_SearchPostByCategory_Call {
+	_c.Call.Return(esaPost, err)
 	return _c
 }
 
-func (_c *MockEsaClientInterface_Search_Call) RunAndReturn(run func(...SearchOption) (*EsaSearchResult, error)) *MockEsaClientInterface_Search_Call {
+func (_c *MockEsaClientInterface_SearchPostByCategory_Call) RunAndReturn(run func(ctx context.Context, category string) (*EsaPost, error)) *MockEsaClientInterface_SearchPostByCategory_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
+// UpdatePost provides a mock function for the type MockEsaClientInterface
+func (_mock *MockEsaClientInterface) UpdatePost(ctx context.Context, existingPost *EsaPost, text string, section string, now time.Time) (*EsaPost, error) {
+	ret := _mock.Called(ctx, existingPost, text, section, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePost")
+	}
+
+	var r0 *EsaPost
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, string, string, time.Time) (*EsaPost, error)); ok {
+		return returnFunc(ctx, existingPost, text, section, now)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *EsaPost, string, string, time.Time) *EsaPost); ok {
+		r0 = returnFunc(ctx, existingPost, text, section, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*EsaPost)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *EsaPost, string, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, existingPost, text, section, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
 // MockEsaClientInterface_UpdatePost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePost'
 type MockEsaClientInterface_UpdatePost_Call struct {
 	*mock.Call
 }
 
 // UpdatePost is a helper method to define mock.On call
+//   - ctx
 //   - existingPost
 //   - text
-func (_e *MockEsaClientInterface_Expecter) UpdatePost(existingPost interface{}, text interface{}) *MockEsaClientInterface_UpdatePost_Call {
-	return &MockEsaClientInterface_UpdatePost_Call{Call: _e.mock.On("UpdatePost", existingPost, text)}
+//   - section
+//   - now
+func (_e *MockEsaClientInterface_Expecter) UpdatePost(ctx interface{}, existingPost interface{}, text interface{}, section interface{}, now interface{}) *MockEsaClientInterface_UpdatePost_Call {
+	return &MockEsaClientInterface_UpdatePost_Call{Call: _e.mock.On("UpdatePost", ctx, existingPost, text, section, now)}
 }
 
-func (_c *MockEsaClientInterface_UpdatePost_Call) Run(run func(existingPost *EsaPost, text string)) *MockEsaClientInterface_UpdatePost_Call {
+func (_c *MockEsaClientInterface_UpdatePost_Call) Run(run func(ctx context.Context, existingPost *EsaPost, text string, section string, now time.Time)) *MockEsaClientInterface_UpdatePost_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*EsaPost), args[1].(string))
+		run(args[0].(context.Context), args[1].(*EsaPost), args[2].(string), args[3].(string), args[4].(time.Time))
 	})
 	return _c
 }
 
-func (_c *MockEsaClientInterface_UpdatePost_Call) Return(esaPost *EsaPost, err error) *MockEsaClientInterface_UpdatePost_Call {
-	_c.Call.Return(esaPost, err)
+func (_c *MockEsaClientInterface_UpdatePost_Call) Return(r *EsaPost, err error) *MockEsaClientInterface_UpdatePost_Call {
+	_c.Call.Return(r, err)
 	return _c
 }
 
-func (_c *MockEsaClientInterface_UpdatePost_Call) RunAndReturn(run func(existingPost *EsaPost, text string) (*EsaPost, error)) *MockEsaClientInterface_UpdatePost_Call {
+func (_c *MockEsaClientInterface_UpdatePost_Call) RunAndReturn(run func(ctx context.Context, existingPost *EsaPost, text string, section string, now time.Time) (*EsaPost, error)) *MockEsaClientInterface_UpdatePost_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -356,3 +985,276 @@ func (_c *MockHTTPClientInterface_Do_Call) RunAndReturn(run func(req *http.Reque
 	_c.Call.Return(run)
 	return _c
 }
+
+// NewMockOutboxStore creates a new instance of MockOutboxStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOutboxStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOutboxStore {
+	mock := &MockOutboxStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockOutboxStore is an autogenerated mock type for the OutboxStore type
+type MockOutboxStore struct {
+	mock.Mock
+}
+
+type MockOutboxStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockOutboxStore) EXPECT() *MockOutboxStore_Expecter {
+	return &MockOutboxStore_Expecter{mock: &_m.Mock}
+}
+
+// Update provides a mock function for the type MockOutboxStore
+func (_mock *MockOutboxStore) Update(fn func(items []OutboxItem) ([]OutboxItem, error)) error {
+	ret := _mock.Called(fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(func(items []OutboxItem) ([]OutboxItem, error)) error); ok {
+		r0 = returnFunc(fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockOutboxStore_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockOutboxStore_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - fn
+func (_e *MockOutboxStore_Expecter) Update(fn interface{}) *MockOutboxStore_Update_Call {
+	return &MockOutboxStore_Update_Call{Call: _e.mock.On("Update", fn)}
+}
+
+func (_c *MockOutboxStore_Update_Call) Run(run func(fn func(items []OutboxItem) ([]OutboxItem, error))) *MockOutboxStore_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(func(items []OutboxItem) ([]OutboxItem, error)))
+	})
+	return _c
+}
+
+func (_c *MockOutboxStore_Update_Call) Return(err error) *MockOutboxStore_Update_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockOutboxStore_Update_Call) RunAndReturn(run func(fn func(items []OutboxItem) ([]OutboxItem, error)) error) *MockOutboxStore_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRateLimitReporter creates a new instance of MockRateLimitReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRateLimitReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRateLimitReporter {
+	mock := &MockRateLimitReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockRateLimitReporter is an autogenerated mock type for the RateLimitReporter type
+type MockRateLimitReporter struct {
+	mock.Mock
+}
+
+type MockRateLimitReporter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRateLimitReporter) EXPECT() *MockRateLimitReporter_Expecter {
+	return &MockRateLimitReporter_Expecter{mock: &_m.Mock}
+}
+
+// RateLimit provides a mock function for the type MockRateLimitReporter
+func (_mock *MockRateLimitReporter) RateLimit() (RateLimit, bool) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RateLimit")
+	}
+
+	var r0 RateLimit
+	var r1 bool
+	if returnFunc, ok := ret.Get(0).(func() (RateLimit, bool)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() RateLimit); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(RateLimit)
+	}
+	if returnFunc, ok := ret.Get(1).(func() bool); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+	return r0, r1
+}
+
+// MockRateLimitReporter_RateLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RateLimit'
+type MockRateLimitReporter_RateLimit_Call struct {
+	*mock.Call
+}
+
+// RateLimit is a helper method to define mock.On call
+func (_e *MockRateLimitReporter_Expecter) RateLimit() *MockRateLimitReporter_RateLimit_Call {
+	return &MockRateLimitReporter_RateLimit_Call{Call: _e.mock.On("RateLimit")}
+}
+
+func (_c *MockRateLimitReporter_RateLimit_Call) Run(run func()) *MockRateLimitReporter_RateLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockRateLimitReporter_RateLimit_Call) Return(rateLimit RateLimit, boolVal bool) *MockRateLimitReporter_RateLimit_Call {
+	_c.Call.Return(rateLimit, boolVal)
+	return _c
+}
+
+func (_c *MockRateLimitReporter_RateLimit_Call) RunAndReturn(run func() (RateLimit, bool)) *MockRateLimitReporter_RateLimit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSimilarityMetric creates a new instance of MockSimilarityMetric. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSimilarityMetric(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSimilarityMetric {
+	mock := &MockSimilarityMetric{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSimilarityMetric is an autogenerated mock type for the SimilarityMetric type
+type MockSimilarityMetric struct {
+	mock.Mock
+}
+
+type MockSimilarityMetric_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSimilarityMetric) EXPECT() *MockSimilarityMetric_Expecter {
+	return &MockSimilarityMetric_Expecter{mock: &_m.Mock}
+}
+
+// Matcher provides a mock function for the type MockSimilarityMetric
+func (_mock *MockSimilarityMetric) Matcher(text string, threshold float64) func(other string) bool {
+	ret := _mock.Called(text, threshold)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Matcher")
+	}
+
+	var r0 func(other string) bool
+	if returnFunc, ok := ret.Get(0).(func(string, float64) func(other string) bool); ok {
+		r0 = returnFunc(text, threshold)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func(other string) bool)
+		}
+	}
+	return r0
+}
+
+// MockSimilarityMetric_Matcher_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Matcher'
+type MockSimilarityMetric_Matcher_Call struct {
+	*mock.Call
+}
+
+// Matcher is a helper method to define mock.On call
+//   - text
+//   - threshold
+func (_e *MockSimilarityMetric_Expecter) Matcher(text interface{}, threshold interface{}) *MockSimilarityMetric_Matcher_Call {
+	return &MockSimilarityMetric_Matcher_Call{Call: _e.mock.On("Matcher", text, threshold)}
+}
+
+func (_c *MockSimilarityMetric_Matcher_Call) Run(run func(text string, threshold float64)) *MockSimilarityMetric_Matcher_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(float64))
+	})
+	return _c
+}
+
+func (_c *MockSimilarityMetric_Matcher_Call) Return(fn func(other string) bool) *MockSimilarityMetric_Matcher_Call {
+	_c.Call.Return(fn)
+	return _c
+}
+
+func (_c *MockSimilarityMetric_Matcher_Call) RunAndReturn(run func(text string, threshold float64) func(other string) bool) *MockSimilarityMetric_Matcher_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Similarity provides a mock function for the type MockSimilarityMetric
+func (_mock *MockSimilarityMetric) Similarity(s1 string, s2 string) float64 {
+	ret := _mock.Called(s1, s2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Similarity")
+	}
+
+	var r0 float64
+	if returnFunc, ok := ret.Get(0).(func(string, string) float64); ok {
+		r0 = returnFunc(s1, s2)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+	return r0
+}
+
+// MockSimilarityMetric_Similarity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Similarity'
+type MockSimilarityMetric_Similarity_Call struct {
+	*mock.Call
+}
+
+// Similarity is a helper method to define mock.On call
+//   - s1
+//   - s2
+func (_e *MockSimilarityMetric_Expecter) Similarity(s1 interface{}, s2 interface{}) *MockSimilarityMetric_Similarity_Call {
+	return &MockSimilarityMetric_Similarity_Call{Call: _e.mock.On("Similarity", s1, s2)}
+}
+
+func (_c *MockSimilarityMetric_Similarity_Call) Run(run func(s1 string, s2 string)) *MockSimilarityMetric_Similarity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockSimilarityMetric_Similarity_Call) Return(float64Val float64) *MockSimilarityMetric_Similarity_Call {
+	_c.Call.Return(float64Val)
+	return _c
+}
+
+func (_c *MockSimilarityMetric_Similarity_Call) RunAndReturn(run func(s1 string, s2 string) float64) *MockSimilarityMetric_Similarity_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -1,18 +1,138 @@
 package main
 
+import "time"
+
 // EsaConfig はesa.ioへの接続設定を保持する構造体
 type EsaConfig struct {
-	TeamName    string
-	AccessToken string
+	TeamName    string `yaml:"team_name"`
+	AccessToken string `yaml:"access_token"`
+
+	// 日報のカテゴリー・タイトルのテンプレート（text/template形式、空ならデフォルト）
+	CategoryTemplate string `yaml:"category_template"`
+	TitleTemplate    string `yaml:"title_template"`
+	// 日報の作成時の本文のテンプレート（text/template形式、空なら最初のエントリのみ）
+	BodyTemplate string `yaml:"body_template"`
+	// 日報の作成時の本文に使うesa.ioのテンプレートの投稿の番号またはカテゴリー（body_templateとはどれか1つを指定する）
+	TemplatePostID   int    `yaml:"template_post_id"`
+	TemplateCategory string `yaml:"template_category"`
+	// 日報の作成時に付けるタグ
+	Tags []string `yaml:"tags"`
+	// テンプレートの{{.ScreenName}}に使うscreen_name（空ならAPIから取得）
+	ScreenName string `yaml:"screen_name"`
+	// 日報にエントリを書く位置（prepend・append・insert_by_time、空ならprepend）
+	EntryPlacement string `yaml:"entry_placement"`
+
+	// esa.io APIのリクエストのタイムアウト
+	Timeout time.Duration `yaml:"timeout"`
+	// 投稿テキストの先頭から除去するプレフィックス
+	Prefix string `yaml:"prefix"`
+	// 重複投稿を防ぐデバウンスの設定
+	Debounce DebounceConfig `yaml:"debounce"`
+	// デバウンスの履歴の保存先（空ならユーザーのキャッシュディレクトリ、"memory"ならプロセス内のみ）
+	DebounceStore string `yaml:"debounce_store"`
+	// 同じ日の日報が複数存在する場合の扱い（oldest・newest・merge・error、空ならoldest）
+	DuplicatePolicy string `yaml:"duplicate_policy"`
+	// 日報の日付と投稿時刻を求めるIANAのタイムゾーン（空ならプロセスのタイムゾーン）
+	TimeZone string `yaml:"time_zone"`
+	// 日付が変わる時（0〜23、4なら4時より前の投稿は前日の日報に書く）
+	DayRolloverHour int `yaml:"day_rollover_hour"`
+
+	// esa.ioに接続できない間の投稿を保留するアウトボックスの設定
+	Outbox OutboxConfig `yaml:"outbox"`
+	// MCPクライアントとの通信の設定
+	Server ServerConfig `yaml:"server"`
+
+	// 複数チームのプロファイル（キーはプロファイル名）
+	Teams map[string]TeamProfile `yaml:"teams"`
+	// teamを省略したときに使うプロファイル名
+	DefaultTeam string `yaml:"default_team"`
+}
+
+// OutboxConfig はアウトボックスの設定を保持する構造体
+type OutboxConfig struct {
+	// 保存先（空ならユーザーのキャッシュディレクトリ、"memory"ならプロセス内のみ、"off"なら保留しない）
+	Store string `yaml:"store"`
+	// 保留した投稿をやり直す間隔
+	FlushInterval time.Duration `yaml:"flush_interval"`
+}
+
+// ServerConfig はMCPクライアントとの通信の設定を保持する構造体
+type ServerConfig struct {
+	// トランスポート（stdio・http、空ならstdio）
+	Transport string `yaml:"transport"`
+	// httpの場合に待ち受けるアドレス
+	Listen string `yaml:"listen"`
+	// httpの場合にMCPクライアントに要求するBearerトークン
+	AuthToken string `yaml:"auth_token"`
+}
+
+// TeamProfile はチームごとの設定を保持する構造体
+// 空の項目は共通の設定を引き継ぐ（TeamNameのみ、空ならプロファイル名を使う）
+type TeamProfile struct {
+	TeamName         string              `yaml:"team_name"`
+	AccessToken      string              `yaml:"access_token"`
+	CategoryTemplate string              `yaml:"category_template"`
+	TitleTemplate    string              `yaml:"title_template"`
+	BodyTemplate     string              `yaml:"body_template"`
+	TemplatePostID   int                 `yaml:"template_post_id"`
+	TemplateCategory string              `yaml:"template_category"`
+	Tags             []string            `yaml:"tags"`
+	ScreenName       string              `yaml:"screen_name"`
+	EntryPlacement   string              `yaml:"entry_placement"`
+	Debounce         *TeamDebounceConfig `yaml:"debounce"`
+}
+
+// TeamDebounceConfig はプロファイルごとのデバウンスの設定
+// 指定しなかった項目（nil）は共通の設定を引き継ぐ
+type TeamDebounceConfig struct {
+	Duration            *time.Duration `yaml:"duration"`
+	SimilarityThreshold *float64       `yaml:"similarity_threshold"`
+	Remote              *bool          `yaml:"remote"`
+	Metric              *string        `yaml:"metric"`
+}
+
+// apply は共通のデバウンスの設定baseに、プロファイルで指定した項目だけを上書きした設定を返す
+func (d TeamDebounceConfig) apply(base DebounceConfig) DebounceConfig {
+	if d.Duration != nil {
+		base.Duration = *d.Duration
+	}
+	if d.SimilarityThreshold != nil {
+		base.SimilarityThreshold = *d.SimilarityThreshold
+	}
+	if d.Remote != nil {
+		base.Remote = *d.Remote
+	}
+	if d.Metric != nil {
+		base.Metric = *d.Metric
+	}
+	return base
 }
 
 // EsaPost はesa.ioの投稿データを表す構造体
 type EsaPost struct {
-	BodyMd   string   `json:"body_md"`
-	BodyHtml string   `json:"body_html"`
-	Number   int      `json:"number"`
-	Name     string   `json:"name"`
-	Tags     []string `json:"tags"`
+	BodyMd         string    `json:"body_md"`
+	BodyHtml       string    `json:"body_html"`
+	Number         int       `json:"number"`
+	Name           string    `json:"name"`
+	Category       string    `json:"category"`
+	Tags           []string  `json:"tags"`
+	RevisionNumber int       `json:"revision_number"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	UpdatedBy      *EsaUser  `json:"updated_by,omitempty"`
+	Overlapped     bool      `json:"overlapped,omitempty"`
+}
+
+// EsaUser はesa.ioのユーザーを表す構造体
+type EsaUser struct {
+	Name       string `json:"name"`
+	ScreenName string `json:"screen_name"`
+}
+
+// RateLimit はesa.io APIのレート制限の状況を表す構造体
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
 }
 
 // EsaSearchResult は検索結果を表す構造体
@@ -33,3 +153,10 @@ type DailyReportResponse struct {
 	Message string  `json:"message"`
 	Post    EsaPost `json:"post,omitempty"`
 }
+
+// DailyReportEntry は日報内の1件分のエントリを表す構造体
+type DailyReportEntry struct {
+	Time     string `json:"time"`
+	AnchorID string `json:"anchor_id"`
+	Text     string `json:"text"`
+}
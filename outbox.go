@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// outboxDisabledName はアウトボックスを使わない指定
+	outboxDisabledName = "off"
+	// outboxMemoryName は保留した投稿をプロセス内だけで保持する指定
+	outboxMemoryName = "memory"
+
+	// defaultOutboxFlushInterval はアウトボックスの投稿をやり直す間隔のデフォルト
+	defaultOutboxFlushInterval = time.Minute
+)
+
+// OutboxItem はesa.ioに接続できずに保留した投稿
+type OutboxItem struct {
+	ID string `json:"id"`
+	// 投稿先のチーム（プロファイル名、空なら共通の設定）
+	Team string `json:"team,omitempty"`
+	// 投稿するテキスト（プレフィックスを除去した後のもの）
+	Text string `json:"text"`
+	// 投稿先の日報のカテゴリー（保留した時点で求められなかった場合は空）
+	Category string `json:"category,omitempty"`
+	// 投稿先の日報の見出し（空なら設定した位置）
+	Section string `json:"section,omitempty"`
+	// 元の投稿時刻（やり直すときもこの時刻の日報にこの時刻で追記する）
+	CreatedAt time.Time `json:"created_at"`
+	// やり直した回数と最後に失敗した理由
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// OutboxStore は保留した投稿を古い順に保持するストア
+type OutboxStore interface {
+	// Update は保留した投稿の一覧をfnに渡し、fnが返した一覧を保存する
+	// 読み込みから保存までは他の呼び出し（他のプロセスを含む）と排他的に行う
+	Update(fn func(items []OutboxItem) ([]OutboxItem, error)) error
+}
+
+// memoryOutboxStore はプロセス内で保留した投稿を保持するOutboxStore
+type memoryOutboxStore struct {
+	mu    sync.Mutex
+	items []OutboxItem
+}
+
+func (s *memoryOutboxStore) Update(fn func(items []OutboxItem) ([]OutboxItem, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := fn(append([]OutboxItem(nil), s.items...))
+	if err != nil {
+		return err
+	}
+	s.items = items
+	return nil
+}
+
+// fileOutboxStore はJSONファイルで保留した投稿を保持するOutboxStore
+// 読み書きはファイルロックで排他するため、同時に動いている複数のプロセスで同じファイルを共有できる
+type fileOutboxStore struct {
+	path string
+
+	// 同じプロセス内の排他（ファイルロックはプロセス間の排他に使う）
+	mu sync.Mutex
+}
+
+func (s *fileOutboxStore) Update(fn func(items []OutboxItem) ([]OutboxItem, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return updateLockedFile(s.path, "アウトボックス", func(data []byte) ([]byte, error) {
+		var items []OutboxItem
+		if len(data) > 0 {
+			// 保留した投稿が失われないよう、壊れたファイルは上書きせずにエラーにする
+			if err := json.Unmarshal(data, &items); err != nil {
+				return nil, fmt.Errorf("アウトボックス %s の解析に失敗: %w", s.path, err)
+			}
+		}
+
+		items, err := fn(items)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("アウトボックスのJSON変換に失敗: %w", err)
+		}
+		return data, nil
+	})
+}
+
+// defaultOutboxStorePath はアウトボックスのデフォルトの保存先（ユーザーのキャッシュディレクトリ）を返す
+func defaultOutboxStorePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "times-esa", "outbox.json"), nil
+}
+
+// Outbox はesa.ioに接続できずに保留した投稿を保存し、後から順にやり直す
+type Outbox struct {
+	store OutboxStore
+
+	// 同じプロセス内で同じ投稿を同時にやり直さないための排他
+	flushMu sync.Mutex
+}
+
+// NewOutbox は設定に応じたOutboxを返す
+// "off"ならnil（保留しない）、"memory"ならプロセス内のみ、空ならユーザーのキャッシュディレクトリ、それ以外は指定したパスのファイルに保存する
+func NewOutbox(spec string) (*Outbox, error) {
+	switch spec {
+	case outboxDisabledName:
+		return nil, nil
+	case outboxMemoryName:
+		return &Outbox{store: &memoryOutboxStore{}}, nil
+	case "":
+		path, err := defaultOutboxStorePath()
+		if err != nil {
+			return nil, fmt.Errorf("アウトボックスの保存先を決められません: %w", err)
+		}
+		return &Outbox{store: &fileOutboxStore{path: path}}, nil
+	default:
+		return &Outbox{store: &fileOutboxStore{path: spec}}, nil
+	}
+}
+
+// Enqueue は投稿を保留する（IDを割り当てて返す）
+func (o *Outbox) Enqueue(item OutboxItem) (OutboxItem, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return OutboxItem{}, fmt.Errorf("IDの生成に失敗: %w", err)
+	}
+	item.ID = hex.EncodeToString(id)
+
+	err := o.store.Update(func(items []OutboxItem) ([]OutboxItem, error) {
+		return append(items, item), nil
+	})
+	if err != nil {
+		return OutboxItem{}, err
+	}
+	return item, nil
+}
+
+// List は保留している投稿を古い順に返す
+func (o *Outbox) List() ([]OutboxItem, error) {
+	var list []OutboxItem
+	err := o.store.Update(func(items []OutboxItem) ([]OutboxItem, error) {
+		list = items
+		return items, nil
+	})
+	return list, err
+}
+
+// Drop は指定したIDの保留している投稿を取り消し、取り消した投稿を返す
+func (o *Outbox) Drop(ids []string) ([]OutboxItem, error) {
+	targets := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		targets[id] = true
+	}
+
+	var dropped []OutboxItem
+	err := o.store.Update(func(items []OutboxItem) ([]OutboxItem, error) {
+		kept := make([]OutboxItem, 0, len(items))
+		for _, item := range items {
+			if targets[item.ID] {
+				dropped = append(dropped, item)
+				delete(targets, item.ID)
+				continue
+			}
+			kept = append(kept, item)
+		}
+		for _, id := range ids {
+			if targets[id] {
+				return nil, newToolError(ErrNotFound, "保留している投稿%sはありません", id)
+			}
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
+
+// Flush は保留している投稿を古い順に元の時刻の日報へ投稿し、投稿できたものを返す
+// 同じチームの投稿は順序を保つため、失敗した時点でそのチームの残りは次の機会に回す
+// esa.ioへのリクエストはストアのロックの外で行い、その間も投稿の保留や一覧を妨げない
+func (o *Outbox) Flush(ctx context.Context, newClient func(team string) (EsaClientInterface, error)) ([]OutboxItem, error) {
+	o.flushMu.Lock()
+	defer o.flushMu.Unlock()
+
+	items, err := o.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var posted []OutboxItem
+	postedIDs := make(map[string]bool)
+	failures := make(map[string]string)
+	failedTeams := make(map[string]bool)
+	for _, item := range items {
+		if failedTeams[item.Team] || ctx.Err() != nil {
+			continue
+		}
+		if err := replayOutboxItem(ctx, newClient, item); err != nil {
+			failures[item.ID] = err.Error()
+			failedTeams[item.Team] = true
+			continue
+		}
+		posted = append(posted, item)
+		postedIDs[item.ID] = true
+	}
+
+	// やり直している間に保留・取り消しされた投稿はそのまま残し、結果だけを反映する
+	err = o.store.Update(func(items []OutboxItem) ([]OutboxItem, error) {
+		kept := make([]OutboxItem, 0, len(items))
+		for _, item := range items {
+			if postedIDs[item.ID] {
+				continue
+			}
+			if lastError, ok := failures[item.ID]; ok {
+				item.Attempts++
+				item.LastError = lastError
+			}
+			kept = append(kept, item)
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posted, nil
+}
+
+// replayOutboxItem は保留した投稿を元の時刻の日報に投稿する
+func replayOutboxItem(ctx context.Context, newClient func(team string) (EsaClientInterface, error), item OutboxItem) error {
+	esaClient, err := newClient(item.Team)
+	if err != nil {
+		return err
+	}
+
+	category := item.Category
+	if category == "" {
+		category, err = esaClient.DailyReportCategory(ctx, item.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("カテゴリーの生成に失敗しました: %w", err)
+		}
+	}
+
+	existingPost, err := esaClient.SearchPostByCategory(ctx, category)
+	if err != nil {
+		return fmt.Errorf("投稿の検索に失敗しました: %w", err)
+	}
+	if existingPost != nil && hasOutboxEntry(existingPost.BodyMd, item) {
+		// 前回のやり直しで書き込みが届いていた場合は、二重に書かずに投稿済みとする
+		return nil
+	}
+	if existingPost == nil {
+		if _, err := esaClient.CreatePost(ctx, item.Text, item.Section, item.CreatedAt); err != nil {
+			return fmt.Errorf("新規投稿の作成に失敗しました: %w", err)
+		}
+		return nil
+	}
+	// 保留している間に他の端末から書かれたエントリがあっても、元の時刻の位置に挿入する
+	// コンフリクトマーカー付きで保存された場合も書き込みは届いているため、やり直すと二重に書いてしまう
+	if _, err := esaClient.InsertEntry(ctx, existingPost, item.Text, item.Section, item.CreatedAt); err != nil && !errors.Is(err, ErrOverlapped) {
+		return fmt.Errorf("投稿の更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// uncertainWriteError は書き込みのリクエストがesa.ioに届いたかどうかわからない失敗を表す
+// 送信後の切断やタイムアウト、5xxの場合はesa.io側で保存されている可能性があるため、保留してやり直すと二重に書き込むおそれがある
+type uncertainWriteError struct {
+	err error
+}
+
+func (e *uncertainWriteError) Error() string {
+	return e.err.Error()
+}
+
+func (e *uncertainWriteError) Unwrap() error {
+	return e.err
+}
+
+// writeFailure は書き込みのリクエスト（POST・PATCH）の失敗errを、esa.ioに届いた可能性がある場合はuncertainWriteErrorで包んで返す
+// 接続を拒否された場合、名前解決に失敗した場合、レート制限で拒否された場合は処理されていないのでそのまま返す
+func writeFailure(err error) error {
+	if !isOutboxRetryable(err) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, ErrRateLimited) {
+		return err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return err
+	}
+	return &uncertainWriteError{err: err}
+}
+
+// hasOutboxEntry は日報の本文に、保留した投稿itemと同じ時刻・同じ本文のエントリがあるかどうかを返す
+// 投稿時刻は保留したときの日報のタイムゾーンのまま保存されているので、そのまま時刻を比べる
+func hasOutboxEntry(bodyMd string, item OutboxItem) bool {
+	hhmm := item.CreatedAt.Format("15:04")
+	text := strings.TrimSpace(item.Text)
+	for _, entry := range ParseDailyReportEntries(bodyMd) {
+		if entry.Time == hhmm && entry.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutboxRetryable はerrがesa.ioに接続できない一時的な障害によるもので、後からやり直せば投稿できるかどうかを返す
+// 書き込みがesa.ioに届いた可能性がある失敗はやり直さない
+func isOutboxRetryable(err error) bool {
+	var uncertain *uncertainWriteError
+	if errors.As(err, &uncertain) {
+		return false
+	}
+	switch toolErrorFor(err).Code {
+	case ErrNetwork.Code, ErrEsaUnavailable.Code, ErrRateLimited.Code:
+		return true
+	}
+	return false
+}
+
+// runOutboxFlusher はintervalごとに保留している投稿をやり直す（ctxが終了するまで続ける）
+func runOutboxFlusher(ctx context.Context, outbox *Outbox, newClient func(team string) (EsaClientInterface, error), interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if items, err := outbox.List(); err == nil && len(items) > 0 {
+			// 失敗した理由は各投稿に記録され、times-esa-outboxで確認できる
+			_, _ = outbox.Flush(ctx, newClient)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// アウトボックスの設定（NewDefaultHandlerFactoryで設定し、nilなら保留しない）
+var (
+	outboxMutex   sync.Mutex
+	defaultOutbox *Outbox
+)
+
+// SetOutbox は投稿を保留するアウトボックスを変更する関数（nilなら保留しない）
+func SetOutbox(outbox *Outbox) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	defaultOutbox = outbox
+}
+
+// currentOutbox は投稿を保留するアウトボックスを返す
+func currentOutbox() *Outbox {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+
+	return defaultOutbox
+}
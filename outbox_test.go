@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbox(t *testing.T) {
+	createdAt := time.Date(2025, 5, 3, 23, 50, 0, 0, time.Local)
+
+	t.Run("ファイルに保存して別のOutboxから読める", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "outbox.json")
+		outbox, err := NewOutbox(path)
+		require.NoError(t, err)
+
+		first, err := outbox.Enqueue(OutboxItem{Text: "1件目", Category: "日報/2025/05/03", CreatedAt: createdAt})
+		require.NoError(t, err)
+		_, err = outbox.Enqueue(OutboxItem{Team: "work", Text: "2件目", CreatedAt: createdAt.Add(time.Minute)})
+		require.NoError(t, err)
+		assert.NotEmpty(t, first.ID)
+
+		// 再起動しても保留した投稿が残る
+		reopened, err := NewOutbox(path)
+		require.NoError(t, err)
+		items, err := reopened.List()
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "1件目", items[0].Text)
+		assert.True(t, createdAt.Equal(items[0].CreatedAt))
+		assert.Equal(t, "work", items[1].Team)
+
+		// 取り消し
+		dropped, err := reopened.Drop([]string{first.ID})
+		require.NoError(t, err)
+		require.Len(t, dropped, 1)
+		assert.Equal(t, "1件目", dropped[0].Text)
+
+		_, err = reopened.Drop([]string{"unknown"})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		items, err = outbox.List()
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "2件目", items[0].Text)
+	})
+
+	t.Run("壊れたファイルは上書きしない", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "outbox.json")
+		require.NoError(t, os.WriteFile(path, []byte("{broken"), 0o600))
+
+		outbox, err := NewOutbox(path)
+		require.NoError(t, err)
+		_, err = outbox.Enqueue(OutboxItem{Text: "テスト", CreatedAt: createdAt})
+		assert.ErrorContains(t, err, "アウトボックス")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{broken", string(data))
+	})
+
+	t.Run("offなら保留しない", func(t *testing.T) {
+		outbox, err := NewOutbox(outboxDisabledName)
+		require.NoError(t, err)
+		assert.Nil(t, outbox)
+	})
+}
+
+func TestOutbox_Flush(t *testing.T) {
+	day1 := time.Date(2025, 5, 3, 23, 50, 0, 0, time.Local)
+	day2 := time.Date(2025, 5, 4, 0, 10, 0, 0, time.Local)
+	unavailable := newToolError(ErrEsaUnavailable, "service_unavailable: Service Unavailable")
+
+	outbox, err := NewOutbox(outboxMemoryName)
+	require.NoError(t, err)
+	for _, item := range []OutboxItem{
+		{Team: "work", Text: "前日の作業", Category: "日報/2025/05/03", CreatedAt: day1},
+		{Team: "work", Text: "日付が変わった後の作業", CreatedAt: day2},
+		{Team: "personal", Text: "別のチームの投稿", Category: "日報/2025/05/04", CreatedAt: day2},
+	} {
+		_, err := outbox.Enqueue(item)
+		require.NoError(t, err)
+	}
+
+	// 1回目: workは障害が続き、personalは投稿できる
+	workClient := NewMockEsaClientInterface(t)
+	workClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(nil, unavailable).Once()
+	personalClient := NewMockEsaClientInterface(t)
+	personalClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/04").Return(nil, nil).Once()
+	personalClient.EXPECT().CreatePost(mock.Anything, "別のチームの投稿", "", day2).Return(&EsaPost{Number: 1}, nil).Once()
+	newClient := func(team string) (EsaClientInterface, error) {
+		if team == "work" {
+			return workClient, nil
+		}
+		return personalClient, nil
+	}
+
+	posted, err := outbox.Flush(context.TODO(), newClient)
+	require.NoError(t, err)
+	require.Len(t, posted, 1)
+	assert.Equal(t, "別のチームの投稿", posted[0].Text)
+
+	// 失敗したチームの残りの投稿は順序を保つため試さない
+	items, err := outbox.List()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, 1, items[0].Attempts)
+	assert.Contains(t, items[0].LastError, "Service Unavailable")
+	assert.Equal(t, 0, items[1].Attempts)
+
+	// 2回目: 回復したら古い順に、元の時刻の日報の時刻順の位置へ投稿する
+	existingPost := &EsaPost{Number: 10, Category: "日報/2025/05/03"}
+	var order []string
+	workClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(existingPost, nil).Once()
+	workClient.EXPECT().InsertEntry(mock.Anything, existingPost, "前日の作業", "", day1).RunAndReturn(func(ctx context.Context, post *EsaPost, text string, section string, now time.Time) (*EsaPost, error) {
+		order = append(order, text)
+		return post, nil
+	}).Once()
+	workClient.EXPECT().DailyReportCategory(mock.Anything, day2).Return("日報/2025/05/04", nil).Once()
+	workClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/04").Return(nil, nil).Once()
+	workClient.EXPECT().CreatePost(mock.Anything, "日付が変わった後の作業", "", day2).RunAndReturn(func(ctx context.Context, text string, section string, now time.Time) (*EsaPost, error) {
+		order = append(order, text)
+		return &EsaPost{Number: 11}, nil
+	}).Once()
+
+	posted, err = outbox.Flush(context.TODO(), newClient)
+	require.NoError(t, err)
+	assert.Len(t, posted, 2)
+	assert.Equal(t, []string{"前日の作業", "日付が変わった後の作業"}, order)
+
+	items, err = outbox.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	// 3回目: 前回のやり直しで書き込みが届いていた投稿は、二重に書かずに投稿済みとする
+	_, err = outbox.Enqueue(OutboxItem{Team: "work", Text: "届いていた作業", Category: "日報/2025/05/03", CreatedAt: day1})
+	require.NoError(t, err)
+	written := &EsaPost{Number: 10, BodyMd: "<a id=\"2350\" href=\"#2350\">23:50</a> 届いていた作業\n\n---"}
+	workClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(written, nil).Once()
+
+	posted, err = outbox.Flush(context.TODO(), newClient)
+	require.NoError(t, err)
+	assert.Len(t, posted, 1)
+	items, err = outbox.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestOutbox_FlushWithoutHoldingStore(t *testing.T) {
+	createdAt := time.Date(2025, 5, 3, 13, 0, 0, 0, time.UTC)
+	outbox, err := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"))
+	require.NoError(t, err)
+	_, err = outbox.Enqueue(OutboxItem{Text: "保留した作業", Category: "日報/2025/05/03", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	// やり直している間も、ストアを使う他の呼び出しは待たされない
+	var added OutboxItem
+	mockEsaClient := NewMockEsaClientInterface(t)
+	mockEsaClient.EXPECT().SearchPostByCategory(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+	mockEsaClient.EXPECT().CreatePost(mock.Anything, "保留した作業", "", createdAt).RunAndReturn(func(ctx context.Context, text string, section string, now time.Time) (*EsaPost, error) {
+		var err error
+		added, err = outbox.Enqueue(OutboxItem{Text: "やり直し中に保留した作業", CreatedAt: now})
+		require.NoError(t, err)
+		return &EsaPost{Number: 1}, nil
+	})
+
+	posted, err := outbox.Flush(context.TODO(), func(team string) (EsaClientInterface, error) {
+		return mockEsaClient, nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, posted, 1)
+
+	// 投稿できたものだけを取り除き、やり直し中に保留した投稿は残す
+	items, err := outbox.List()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, added.ID, items[0].ID)
+}
+
+func TestWriteFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		uncertain bool
+	}{
+		{
+			name: "接続を拒否された",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+		},
+		{
+			name: "名前解決に失敗した",
+			err:  &net.DNSError{Err: "no such host", Name: "api.esa.io", IsNotFound: true},
+		},
+		{
+			name: "レート制限",
+			err:  newToolError(ErrRateLimited, "too_many_requests"),
+		},
+		{
+			name: "認証エラー",
+			err:  newToolError(ErrUnauthorized, "unauthorized"),
+		},
+		{
+			name:      "送信後に切断された",
+			err:       &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			uncertain: true,
+		},
+		{
+			name:      "5xx",
+			err:       newToolError(ErrEsaUnavailable, "service_unavailable"),
+			uncertain: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := writeFailure(tt.err)
+			var uncertain *uncertainWriteError
+			assert.Equal(t, tt.uncertain, errors.As(err, &uncertain))
+			assert.Equal(t, tt.err.Error(), err.Error())
+			assert.Equal(t, toolErrorFor(tt.err).Code, toolErrorFor(err).Code)
+			if tt.uncertain {
+				assert.False(t, isOutboxRetryable(err))
+			}
+		})
+	}
+}
+
+func TestSubmitDailyReport_Outbox(t *testing.T) {
+	fixedTime := time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)
+	defer SetOutbox(nil)
+
+	t.Run("esa.ioの障害で投稿できない場合は保留する", func(t *testing.T) {
+		resetDebounce()
+		outbox, err := NewOutbox(outboxMemoryName)
+		require.NoError(t, err)
+		SetOutbox(outbox)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "テスト投稿", "やったこと", fixedTime).Return(nil, newToolError(ErrEsaUnavailable, "service_unavailable"))
+
+		req := &TimesEsaPostRequest{Text: "テスト投稿", ConfirmedByUser: true, Team: "work", Section: "やったこと"}
+		result, err := submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+
+		require.NoError(t, err)
+		require.NotNil(t, result.Queued)
+		assert.Contains(t, result.Message, "投稿を保留しました")
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, OutboxItem{
+			ID:        result.Queued.ID,
+			Team:      "work",
+			Text:      "テスト投稿",
+			Category:  "日報/2025/05/03",
+			Section:   "やったこと",
+			CreatedAt: fixedTime,
+			LastError: "新規投稿の作成に失敗しました: service_unavailable",
+		}, items[0])
+	})
+
+	t.Run("書き込みがesa.ioに届いた可能性がある場合は保留しない", func(t *testing.T) {
+		resetDebounce()
+		outbox, err := NewOutbox(outboxMemoryName)
+		require.NoError(t, err)
+		SetOutbox(outbox)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, nil)
+		mockEsaClient.EXPECT().CreatePost(mock.Anything, "テスト投稿", "", fixedTime).Return(nil, writeFailure(newToolError(ErrEsaUnavailable, "service_unavailable")))
+
+		req := &TimesEsaPostRequest{Text: "テスト投稿", ConfirmedByUser: true}
+		_, err = submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		assert.ErrorIs(t, err, ErrEsaUnavailable)
+		assert.Contains(t, err.Error(), "保存された可能性がある")
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("呼び出しがキャンセルされた場合は保留しない", func(t *testing.T) {
+		resetDebounce()
+		outbox, err := NewOutbox(outboxMemoryName)
+		require.NoError(t, err)
+		SetOutbox(outbox)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").RunAndReturn(func(context.Context, string) (*EsaPost, error) {
+			cancel()
+			return nil, &url.Error{Op: "Get", URL: "https://api.esa.io/v1/teams/test-team/posts", Err: context.Canceled}
+		})
+
+		req := &TimesEsaPostRequest{Text: "テスト投稿", ConfirmedByUser: true}
+		result, err := submitDailyReportWithClock(ctx, nil, req, mockEsaClient, fixedTime)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("一時的でないエラーは保留しない", func(t *testing.T) {
+		resetDebounce()
+		outbox, err := NewOutbox(outboxMemoryName)
+		require.NoError(t, err)
+		SetOutbox(outbox)
+
+		mockEsaClient := NewMockEsaClientInterface(t)
+		mockEsaClient.EXPECT().DailyReportCategory(mock.Anything, fixedTime).Return("日報/2025/05/03", nil)
+		mockEsaClient.EXPECT().ResolveDailyReport(mock.Anything, "日報/2025/05/03").Return(nil, newToolError(ErrUnauthorized, "unauthorized"))
+
+		req := &TimesEsaPostRequest{Text: "テスト投稿", ConfirmedByUser: true}
+		_, err = submitDailyReportWithClock(context.TODO(), nil, req, mockEsaClient, fixedTime)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+
+		items, err := outbox.List()
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+}
+
+func TestManageOutbox(t *testing.T) {
+	outbox, err := NewOutbox(outboxMemoryName)
+	require.NoError(t, err)
+	item, err := outbox.Enqueue(OutboxItem{Text: "テスト", CreatedAt: time.Date(2025, 5, 3, 13, 0, 0, 0, time.Local)})
+	require.NoError(t, err)
+	newClient := func(team string) (EsaClientInterface, error) {
+		return nil, errors.New("呼び出されない")
+	}
+
+	result, err := manageOutbox(context.TODO(), &TimesEsaOutboxRequest{}, outbox, newClient)
+	require.NoError(t, err)
+	assert.Equal(t, []OutboxItem{item}, result.Items)
+	assert.Equal(t, "保留している投稿は1件です", result.Message)
+
+	_, err = manageOutbox(context.TODO(), &TimesEsaOutboxRequest{Action: "drop", IDs: []string{item.ID}}, outbox, newClient)
+	assert.ErrorIs(t, err, ErrNotConfirmed)
+
+	result, err = manageOutbox(context.TODO(), &TimesEsaOutboxRequest{Action: "drop", IDs: []string{item.ID}, ConfirmedByUser: true}, outbox, newClient)
+	require.NoError(t, err)
+	assert.Equal(t, []OutboxItem{item}, result.Dropped)
+	assert.Empty(t, result.Items)
+	assert.Equal(t, "1件の保留した投稿を取り消しました。保留している投稿は0件です", result.Message)
+
+	_, err = manageOutbox(context.TODO(), &TimesEsaOutboxRequest{Action: "retry"}, outbox, newClient)
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = manageOutbox(context.TODO(), &TimesEsaOutboxRequest{}, nil, newClient)
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
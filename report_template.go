@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// defaultCategoryTemplate は日報のカテゴリーのデフォルトテンプレート
+	defaultCategoryTemplate = "日報/{{.Year}}/{{.Month}}/{{.Day}}"
+
+	// defaultTitleTemplate は日報のタイトルのデフォルトテンプレート
+	defaultTitleTemplate = "日報"
+)
+
+// japaneseWeekdays は曜日の日本語表記
+var japaneseWeekdays = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// DailyReportTemplateData はカテゴリーやタイトルのテンプレートに渡す値
+type DailyReportTemplateData struct {
+	Year       string // 4桁の年（例: 2026）
+	Month      string // 2桁の月（例: 10）
+	Day        string // 2桁の日（例: 16）
+	Date       string // ISO 8601形式の日付（例: 2026-10-16）
+	Weekday    string // 英語の曜日の略称（例: Fri）
+	WeekdayJa  string // 日本語の曜日（例: 金）
+	ISOYear    string // ISO週番号の年（例: 2026）
+	ISOWeek    string // 2桁のISO週番号（例: 42）
+	ScreenName string // esa.ioのscreen_name
+}
+
+// NewDailyReportTemplateData は日付とscreen_nameからテンプレートに渡す値を作る
+func NewDailyReportTemplateData(t time.Time, screenName string) DailyReportTemplateData {
+	isoYear, isoWeek := t.ISOWeek()
+	return DailyReportTemplateData{
+		Year:       fmt.Sprintf("%04d", t.Year()),
+		Month:      fmt.Sprintf("%02d", t.Month()),
+		Day:        fmt.Sprintf("%02d", t.Day()),
+		Date:       t.Format("2006-01-02"),
+		Weekday:    t.Format("Mon"),
+		WeekdayJa:  japaneseWeekdays[t.Weekday()],
+		ISOYear:    fmt.Sprintf("%04d", isoYear),
+		ISOWeek:    fmt.Sprintf("%02d", isoWeek),
+		ScreenName: screenName,
+	}
+}
+
+// renderDailyReportTemplate はテンプレートを展開する
+func renderDailyReportTemplate(name, text string, data DailyReportTemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%sのテンプレートの解析に失敗: %w", name, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%sのテンプレートの展開に失敗: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// usesScreenName はテンプレートがscreen_nameを参照しているかどうかを返す（esa.ioのテンプレートの%{me}を含む）
+func usesScreenName(text string) bool {
+	return strings.Contains(text, ".ScreenName") || strings.Contains(text, "%{me}")
+}
+
+// expandEsaTemplate はesa.ioのテンプレートの投稿の日付の記法とscreen_name（%{me}）を、esa.ioで投稿を作成したときと同じように展開する
+// esa.ioのサーバーに任せずに展開するため、日付が変わる時刻より前に作成した日報にも日報の日付が入る
+// 対応していない記法はそのまま残す
+func expandEsaTemplate(text string, data DailyReportTemplateData) string {
+	return strings.NewReplacer(
+		"%{Year}", data.Year,
+		"%{year}", data.Year[len(data.Year)-2:],
+		"%{month}", data.Month,
+		"%{day}", data.Day,
+		"%{week}", data.WeekdayJa,
+		"%{me}", data.ScreenName,
+	).Replace(text)
+}
+
+// categoryTemplate は設定されたカテゴリーのテンプレートを返す（未設定ならデフォルト）
+func (c EsaConfig) categoryTemplate() string {
+	if c.CategoryTemplate == "" {
+		return defaultCategoryTemplate
+	}
+	return c.CategoryTemplate
+}
+
+// titleTemplate は設定されたタイトルのテンプレートを返す（未設定ならデフォルト）
+func (c EsaConfig) titleTemplate() string {
+	if c.TitleTemplate == "" {
+		return defaultTitleTemplate
+	}
+	return c.TitleTemplate
+}
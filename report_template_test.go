@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDailyReportTemplateData(t *testing.T) {
+	data := NewDailyReportTemplateData(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), "test_user")
+
+	assert.Equal(t, DailyReportTemplateData{
+		Year:       "2026",
+		Month:      "10",
+		Day:        "16",
+		Date:       "2026-10-16",
+		Weekday:    "Fri",
+		WeekdayJa:  "金",
+		ISOYear:    "2026",
+		ISOWeek:    "42",
+		ScreenName: "test_user",
+	}, data)
+
+	// 年末年始はISO週番号の年が暦の年と異なる
+	data = NewDailyReportTemplateData(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), "")
+	assert.Equal(t, "2026", data.ISOYear)
+	assert.Equal(t, "53", data.ISOWeek)
+}
+
+func TestEsaClient_DailyReportCategory(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		categoryTemplate string
+		expected         string
+	}{
+		{
+			name:             "デフォルト",
+			categoryTemplate: "",
+			expected:         "日報/2026/10/16",
+		},
+		{
+			name:             "ユーザーごとのtimes",
+			categoryTemplate: "times/{{.ScreenName}}/{{.Year}}/{{.Month}}/{{.Day}}",
+			expected:         "times/test_user/2026/10/16",
+		},
+		{
+			name:             "日付の後にユーザー名",
+			categoryTemplate: "日報/{{.Year}}/{{.Month}}/{{.Day}}/{{.ScreenName}}",
+			expected:         "日報/2026/10/16/test_user",
+		},
+		{
+			name:             "ISO週番号",
+			categoryTemplate: "週報/{{.ISOYear}}/W{{.ISOWeek}}/{{.Date}}",
+			expected:         "週報/2026/W42/2026-10-16",
+		},
+		{
+			name:             "前後のスラッシュは除去",
+			categoryTemplate: "/日報/{{.Year}}/",
+			expected:         "日報/2026",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewEsaClient(NewMockHTTPClientInterface(t), EsaConfig{
+				TeamName:         "test-team",
+				AccessToken:      "test-token",
+				CategoryTemplate: tt.categoryTemplate,
+				ScreenName:       "test_user",
+			})
+
+			category, err := client.DailyReportCategory(context.Background(), now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, category)
+		})
+	}
+
+	t.Run("screen_nameが未設定ならAPIから取得してキャッシュする", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == esaAPIBaseURL+esaUserEndpoint
+		})).Return(jsonResponse(http.StatusOK, `{"name": "テストユーザー", "screen_name": "api_user"}`), nil).Once()
+
+		client := NewEsaClient(mockHTTPClient, EsaConfig{
+			TeamName:         "test-team",
+			AccessToken:      "test-token",
+			CategoryTemplate: "times/{{.ScreenName}}/{{.Year}}/{{.Month}}/{{.Day}}",
+		})
+
+		for i := 0; i < 2; i++ {
+			category, err := client.DailyReportCategory(context.Background(), now)
+			require.NoError(t, err)
+			assert.Equal(t, "times/api_user/2026/10/16", category)
+		}
+	})
+
+	t.Run("不正なテンプレートはエラー", func(t *testing.T) {
+		client := NewEsaClient(NewMockHTTPClientInterface(t), EsaConfig{
+			TeamName:         "test-team",
+			AccessToken:      "test-token",
+			CategoryTemplate: "日報/{{.Unknown}}",
+			ScreenName:       "test_user",
+		})
+
+		_, err := client.DailyReportCategory(context.Background(), now)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "カテゴリーのテンプレート")
+	})
+}
+
+func TestCreatePost_UsesTemplates(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	mockHTTPClient := NewMockHTTPClientInterface(t)
+	mockHTTPClient.EXPECT().Do(methodIs("POST")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Post struct {
+				Name     string   `json:"name"`
+				Category string   `json:"category"`
+				Tags     []string `json:"tags"`
+			} `json:"post"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "日報 2026-10-16 (Fri)", body.Post.Name)
+		assert.Equal(t, "日報/2026/10/16/test_user", body.Post.Category)
+		assert.Equal(t, []string{"日報", "times"}, body.Post.Tags)
+		return jsonResponse(http.StatusCreated, `{"number": 1}`), nil
+	})
+
+	client := NewEsaClient(mockHTTPClient, EsaConfig{
+		TeamName:         "test-team",
+		AccessToken:      "test-token",
+		CategoryTemplate: "日報/{{.Year}}/{{.Month}}/{{.Day}}/{{.ScreenName}}",
+		TitleTemplate:    "日報 {{.Date}} ({{.Weekday}})",
+		Tags:             []string{"日報", "times"},
+		ScreenName:       "test_user",
+	})
+
+	_, err := client.CreatePost(context.Background(), "テスト", "", now)
+	assert.NoError(t, err)
+}
+
+func TestExpandEsaTemplate(t *testing.T) {
+	data := NewDailyReportTemplateData(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), "test_user")
+
+	result := expandEsaTemplate("# %{Year}/%{month}/%{day}（%{week}）%{me}の日報\n%{year}年 %{Hour}", data)
+	// 対応していない記法はそのまま残す
+	assert.Equal(t, "# 2026/10/16（金）test_userの日報\n26年 %{Hour}", result)
+}
+
+func TestCreatePost_EsaTemplate(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	template := `{"number": 42, "category": "Templates/日報", "body_md": "## %{Year}-%{month}-%{day} %{me}\n\n## やったこと\n\n## 気づき\n"}`
+	expected := "## 2026-10-16 test_user\n\n## やったこと\n\n<a id=\"0930\" href=\"#0930\">09:30</a> テスト\n\n---\n\n## 気づき\n"
+
+	// pathIs はリクエストのパスとメソッドで絞り込むマッチャーを返す
+	pathIs := func(method, suffix string) interface{} {
+		return mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == method && strings.HasSuffix(req.URL.Path, suffix)
+		})
+	}
+	// expectCreate は作成する日報の本文を検証する
+	expectCreate := func(mockHTTPClient *MockHTTPClientInterface) {
+		mockHTTPClient.EXPECT().Do(methodIs("POST")).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Post struct {
+					BodyMd string `json:"body_md"`
+				} `json:"post"`
+			}
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			assert.Equal(t, expected, body.Post.BodyMd)
+			return jsonResponse(http.StatusCreated, `{"number": 1}`), nil
+		})
+	}
+	config := EsaConfig{
+		TeamName:    "test-team",
+		AccessToken: "test-token",
+		ScreenName:  "test_user",
+	}
+
+	t.Run("投稿の番号で指定", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(pathIs("GET", "/posts/42")).Return(jsonResponse(http.StatusOK, template), nil)
+		expectCreate(mockHTTPClient)
+
+		config := config
+		config.TemplatePostID = 42
+		_, err := NewEsaClient(mockHTTPClient, config).CreatePost(context.Background(), "テスト", "やったこと", now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("カテゴリーで指定", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(pathIs("GET", "/posts")).Return(jsonResponse(http.StatusOK, `{"posts": [`+template+`]}`), nil)
+		expectCreate(mockHTTPClient)
+
+		config := config
+		config.TemplateCategory = "Templates/日報"
+		_, err := NewEsaClient(mockHTTPClient, config).CreatePost(context.Background(), "テスト", "やったこと", now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("カテゴリーに投稿がなければエラー", func(t *testing.T) {
+		mockHTTPClient := NewMockHTTPClientInterface(t)
+		mockHTTPClient.EXPECT().Do(pathIs("GET", "/posts")).Return(jsonResponse(http.StatusOK, `{"posts": []}`), nil)
+
+		config := config
+		config.TemplateCategory = "Templates/日報"
+		_, err := NewEsaClient(mockHTTPClient, config).CreatePost(context.Background(), "テスト", "", now)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Contains(t, err.Error(), "テンプレートの投稿の取得に失敗")
+	})
+}
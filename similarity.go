@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SimilarityMetric はデバウンスで使うテキストの類似度の計算方法
+type SimilarityMetric interface {
+	// Similarity は2つのテキストの類似度を0.0〜1.0で返す（1.0は同じ内容とみなせる）
+	Similarity(s1, s2 string) float64
+
+	// Matcher はtextとの類似度がthreshold以上かどうかを判定する関数を返す
+	// 1つのテキストを履歴の複数のテキストと比較するため、textの前処理は最初の1回だけ行う
+	Matcher(text string, threshold float64) func(other string) bool
+}
+
+// 類似度の計算方法の名前（DebounceConfig.Metricで指定する）
+const (
+	// 文字単位のレーベンシュタイン距離（デフォルト）
+	metricLevenshtein = "levenshtein"
+	// 正規化（NFKC・空白の統一・句読点の除去）してからのレーベンシュタイン距離
+	metricNormalizedLevenshtein = "normalized_levenshtein"
+	// 正規化した文字の2文字組の集合のJaccard係数
+	metricNgramJaccard = "ngram_jaccard"
+	// 正規化したトークンの出現頻度のコサイン類似度（日本語は2文字組をトークンにする）
+	metricTokenCosine = "token_cosine"
+)
+
+// similarityMetrics は名前で指定できる類似度の計算方法
+var similarityMetrics = map[string]SimilarityMetric{
+	metricLevenshtein:           levenshteinMetric{},
+	metricNormalizedLevenshtein: levenshteinMetric{normalize: true},
+	metricNgramJaccard:          ngramJaccardMetric{n: 2},
+	metricTokenCosine:           tokenCosineMetric{},
+}
+
+// SimilarityMetricNames は指定できる類似度の計算方法の名前を返す
+func SimilarityMetricNames() []string {
+	names := make([]string, 0, len(similarityMetrics))
+	for name := range similarityMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSimilarityMetric は名前に対応するSimilarityMetricを返す（空ならレーベンシュタイン距離）
+func NewSimilarityMetric(name string) (SimilarityMetric, error) {
+	if name == "" {
+		name = metricLevenshtein
+	}
+	metric, ok := similarityMetrics[name]
+	if !ok {
+		return nil, fmt.Errorf("類似度の計算方法%sはありません（%s）", name, strings.Join(SimilarityMetricNames(), "・"))
+	}
+	return metric, nil
+}
+
+// similarityMetricFor はデバウンス設定の類似度の計算方法を返す
+// 設定は起動時に検証されるため、未知の名前はデフォルトとして扱う
+func similarityMetricFor(config DebounceConfig) SimilarityMetric {
+	metric, err := NewSimilarityMetric(config.Metric)
+	if err != nil {
+		return levenshteinMetric{}
+	}
+	return metric
+}
+
+// levenshteinMetric はレーベンシュタイン距離による類似度
+type levenshteinMetric struct {
+	// normalizeがtrueならnormalizeForSimilarityで正規化してから比較する
+	normalize bool
+}
+
+func (m levenshteinMetric) Similarity(s1, s2 string) float64 {
+	return textSimilarity(m.prepare(s1), m.prepare(s2))
+}
+
+func (m levenshteinMetric) Matcher(text string, threshold float64) func(other string) bool {
+	matcher := newSimilarityMatcher(m.prepare(text), threshold)
+	return func(other string) bool {
+		return matcher.match(m.prepare(other))
+	}
+}
+
+// prepare は比較する前のテキストを返す
+func (m levenshteinMetric) prepare(text string) string {
+	if m.normalize {
+		return normalizeForSimilarity(text)
+	}
+	return text
+}
+
+// ngramJaccardMetric は正規化した文字のn文字組の集合のJaccard係数による類似度
+// 語順の入れ替えや追記に強く、単語の区切りがない日本語でもそのまま使える
+type ngramJaccardMetric struct {
+	n int
+}
+
+func (m ngramJaccardMetric) Similarity(s1, s2 string) float64 {
+	return jaccard(m.ngrams(s1), m.ngrams(s2))
+}
+
+func (m ngramJaccardMetric) Matcher(text string, threshold float64) func(other string) bool {
+	grams := m.ngrams(text)
+	return func(other string) bool {
+		return jaccard(grams, m.ngrams(other)) >= threshold
+	}
+}
+
+// ngrams は正規化したテキストのn文字組の集合を返す（n文字に満たない場合はテキスト全体を1つの組とする）
+func (m ngramJaccardMetric) ngrams(text string) map[string]struct{} {
+	runes := []rune(normalizeForSimilarity(text))
+	grams := make(map[string]struct{})
+	if len(runes) == 0 {
+		return grams
+	}
+	if len(runes) < m.n {
+		grams[string(runes)] = struct{}{}
+		return grams
+	}
+	for i := m.n; i <= len(runes); i++ {
+		grams[string(runes[i-m.n:i])] = struct{}{}
+	}
+	return grams
+}
+
+// jaccard は2つの集合のJaccard係数を返す（両方とも空なら1.0）
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	intersection := 0
+	for gram := range a {
+		if _, ok := b[gram]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// tokenCosineMetric は正規化したトークンの出現頻度のコサイン類似度による類似度
+// 英語などは単語、日本語（漢字・ひらがな・カタカナ）は連続する部分の2文字組をトークンにする
+type tokenCosineMetric struct{}
+
+func (tokenCosineMetric) Similarity(s1, s2 string) float64 {
+	return cosine(tokenFrequencies(s1), tokenFrequencies(s2))
+}
+
+func (tokenCosineMetric) Matcher(text string, threshold float64) func(other string) bool {
+	frequencies := tokenFrequencies(text)
+	return func(other string) bool {
+		return cosine(frequencies, tokenFrequencies(other)) >= threshold
+	}
+}
+
+// tokenFrequencies は正規化したテキストのトークンごとの出現回数を返す
+// 句読点は取り除かずに単語の区切りとして扱う（URLやバージョン番号が1つのトークンにつながらないように）
+func tokenFrequencies(text string) map[string]int {
+	frequencies := make(map[string]int)
+	for _, token := range tokenize(normalizeWidth(text)) {
+		frequencies[token]++
+	}
+	return frequencies
+}
+
+// tokenize はテキストをトークンに分割する
+// 文字・数字の連続は1つの単語とし、日本語の連続は単語の区切りがないため2文字組に分ける
+// 空白と句読点は区切りとして扱い、トークンにしない
+func tokenize(text string) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		class := runeClass(runes[i])
+		j := i + 1
+		for j < len(runes) && runeClass(runes[j]) == class {
+			j++
+		}
+
+		switch run := runes[i:j]; {
+		case class == runeClassSpace:
+		case class == runeClassJapanese && len(run) > 1:
+			for k := 2; k <= len(run); k++ {
+				tokens = append(tokens, string(run[k-2:k]))
+			}
+		default:
+			tokens = append(tokens, string(run))
+		}
+		i = j
+	}
+	return tokens
+}
+
+// 文字の種類（tokenizeで連続する部分をまとめるために使う）
+const (
+	runeClassSpace = iota // 空白・句読点
+	runeClassWord
+	runeClassJapanese
+	runeClassOther
+)
+
+// runeClass は文字の種類を返す
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r) || unicode.IsPunct(r):
+		return runeClassSpace
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー':
+		return runeClassJapanese
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return runeClassWord
+	default:
+		return runeClassOther
+	}
+}
+
+// cosine は2つの出現頻度のコサイン類似度を返す（両方とも空なら1.0）
+func cosine(a, b map[string]int) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	var dot, normA, normB float64
+	for token, count := range a {
+		dot += float64(count * b[token])
+		normA += float64(count * count)
+	}
+	for _, count := range b {
+		normB += float64(count * count)
+	}
+	if normA == 0 || normB == 0 {
+		return 0.0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// similarityURLPattern はURLのパスとクエリ文字列・フラグメントを分けるためのパターン
+// URLはASCIIの記号と英数字（?と#は区切りとして除く）だけが続く範囲とし、空白なしで続く日本語の文章を含めない
+var similarityURLPattern = regexp.MustCompile(`(https?://[\x21\x22\x24-\x3e\x40-\x7e]*)(\?[\x21\x22\x24-\x7e]*)?(#[\x21-\x7e]*)?`)
+
+// trackingQueryParams は共有元を記録するだけで、リンク先を変えないクエリパラメーター（utm_*以外）
+var trackingQueryParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+}
+
+// normalizeWidth はNFKCで全角・半角をそろえて小文字にし、URLからトラッキング用のクエリパラメーターを取り除く
+// 他のクエリパラメーターとフラグメントはリンク先を区別するため残す
+func normalizeWidth(text string) string {
+	text = strings.ToLower(norm.NFKC.String(text))
+	return similarityURLPattern.ReplaceAllStringFunc(text, func(link string) string {
+		m := similarityURLPattern.FindStringSubmatch(link)
+		return m[1] + stripTrackingParams(m[2]) + m[3]
+	})
+}
+
+// stripTrackingParams はURLのクエリ文字列query（先頭の?を含む）からトラッキング用のパラメーターを取り除く
+func stripTrackingParams(query string) string {
+	if query == "" {
+		return ""
+	}
+	var kept []string
+	for _, param := range strings.Split(query[1:], "&") {
+		key, _, _ := strings.Cut(param, "=")
+		if strings.HasPrefix(key, "utm_") || trackingQueryParams[key] {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(kept, "&")
+}
+
+// normalizeForSimilarity は表記の揺れで類似度が変わらないようにテキストを正規化する
+// normalizeWidthに加えて句読点を取り除き、空白を1つにまとめる
+// URLの中の記号（/ ? = &など）はリンク先を区別するため、URLは取り除かずにそのまま残す
+func normalizeForSimilarity(text string) string {
+	text = normalizeWidth(text)
+
+	var b strings.Builder
+	space := false
+	write := func(segment string, keepPunct bool) {
+		for _, r := range segment {
+			switch {
+			case unicode.IsPunct(r) && !keepPunct:
+				continue
+			case unicode.IsSpace(r):
+				space = true
+				continue
+			}
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		}
+	}
+
+	prev := 0
+	for _, loc := range similarityURLPattern.FindAllStringIndex(text, -1) {
+		write(text[prev:loc[0]], false)
+		write(text[loc[0]:loc[1]], true)
+		prev = loc[1]
+	}
+	write(text[prev:], false)
+	return b.String()
+}
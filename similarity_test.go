@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// similarityCorpus は類似度の計算方法を比べるための日本語・英語のテキストの組
+var similarityCorpus = []struct {
+	name      string
+	s1, s2    string
+	duplicate bool
+}{
+	// 同じ内容とみなすべき組
+	{name: "語尾の違い", s1: "確認した", s2: "確認しました。", duplicate: true},
+	{name: "語尾と記号の違い", s1: "PRのレビューを依頼した", s2: "PRのレビューを依頼しました！", duplicate: true},
+	{name: "全角と半角", s1: "ＡＰＩのテストを書いた", s2: "APIのテストを書いた", duplicate: true},
+	{name: "URLのトラッキング用のクエリ文字列", s1: "デプロイ完了 https://example.com/deploy/123?utm_source=slack&utm_medium=chat", s2: "デプロイ完了 https://example.com/deploy/123", duplicate: true},
+	{name: "空白と改行", s1: "今日は  ミーティング\nが3件", s2: "今日は ミーティング が３件", duplicate: true},
+	{name: "英語の大文字小文字と句読点", s1: "Fixed the flaky test in CI.", s2: "fixed the flaky test in CI", duplicate: true},
+	{name: "英語の空白と記号", s1: "Deployed v1.2.3 to production", s2: "Deployed  v1.2.3 to   production!", duplicate: true},
+
+	// 別の内容とみなすべき組
+	{name: "別の作業", s1: "確認した", s2: "実装した", duplicate: false},
+	{name: "別の食事", s1: "ランチはカレーを食べた", s2: "夕飯はラーメンを食べた", duplicate: false},
+	{name: "同じPRの別の操作", s1: "PRのレビューを依頼した", s2: "PRをマージした", duplicate: false},
+	{name: "同じ日の別の作業", s1: "今日は新しいAPIの設計をした", s2: "今日はAPIのテストを書いた", duplicate: false},
+	{name: "英語の別の作業", s1: "Fixed the flaky test in CI", s2: "Added a new test for the parser", duplicate: false},
+	{name: "英語の無関係な内容", s1: "Deployed v1.2.3 to production", s2: "Rolled back the release because of errors", duplicate: false},
+}
+
+func TestSimilarityMetrics(t *testing.T) {
+	// 計算方法ごとに、コーパスを正しく判定できるしきい値
+	thresholds := map[string]float64{
+		metricNormalizedLevenshtein: 0.6,
+		metricNgramJaccard:          0.6,
+		metricTokenCosine:           0.7,
+	}
+
+	for name, threshold := range thresholds {
+		metric, err := NewSimilarityMetric(name)
+		require.NoError(t, err)
+
+		for _, tt := range similarityCorpus {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				similarity := metric.Similarity(tt.s1, tt.s2)
+				assert.GreaterOrEqual(t, similarity, 0.0)
+				assert.LessOrEqual(t, similarity, 1.0)
+				assert.Equal(t, tt.duplicate, similarity >= threshold, "類似度: %.2f", similarity)
+
+				// Matcherの判定はSimilarityと一致し、比較の向きによらない
+				assert.Equal(t, similarity >= threshold, metric.Matcher(tt.s1, threshold)(tt.s2))
+				assert.InDelta(t, similarity, metric.Similarity(tt.s2, tt.s1), 1e-9)
+			})
+		}
+	}
+}
+
+func TestLevenshteinMetric(t *testing.T) {
+	// デフォルトの計算方法は正規化せずにtextSimilarityと同じ値を返す
+	metric, err := NewSimilarityMetric("")
+	require.NoError(t, err)
+	for _, tt := range similarityCorpus {
+		assert.Equal(t, textSimilarity(tt.s1, tt.s2), metric.Similarity(tt.s1, tt.s2), tt.name)
+	}
+
+	// 正規化しないと表記の揺れだけの組も別の内容と判定される
+	assert.False(t, metric.Matcher("ＡＰＩのテストを書いた", 0.9)("APIのテストを書いた"))
+
+	_, err = NewSimilarityMetric("soundex")
+	assert.ErrorContains(t, err, "soundex")
+}
+
+func TestNormalizeForSimilarity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "確認しました。", expected: "確認しました"},
+		{input: "ＡＰＩ　テスト", expected: "api テスト"},
+		{input: "  今日は\n\tミーティング  ", expected: "今日は ミーティング"},
+		{input: "見て https://example.com/a?b=c#d ください", expected: "見て https://example.com/a?b=c#d ください"},
+		{input: "見て https://example.com/a?utm_source=x&b=c&fbclid=y ください", expected: "見て https://example.com/a?b=c ください"},
+		{input: "「https://example.com/a?b=c&d=e」を確認。", expected: "https://example.com/a?b=c&d=eを確認"},
+		{input: "「了解」です！", expected: "了解です"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, normalizeForSimilarity(tt.input), tt.input)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "fixed the test", expected: []string{"fixed", "the", "test"}},
+		{input: "v1.2.3", expected: []string{"v1", "2", "3"}},
+		{input: "確認した", expected: []string{"確認", "認し", "した"}},
+		{input: "APIのテスト", expected: []string{"API", "のテ", "テス", "スト"}},
+		{input: "了解 ok", expected: []string{"了解", "ok"}},
+		{input: "。", expected: nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tokenize(tt.input), tt.input)
+	}
+}
+
+func TestIsDebouncedWithMetric(t *testing.T) {
+	resetDebounce()
+	SetTeamDebounceConfig("normalized", DebounceConfig{Duration: time.Minute, SimilarityThreshold: 0.6, Metric: metricNormalizedLevenshtein})
+	defer func() {
+		debounceMutex.Lock()
+		delete(teamDebounceConfigs, "normalized")
+		debounceMutex.Unlock()
+	}()
+
+	// 正規化すると語尾の違いだけの投稿は同じ内容とみなされる
+	assert.False(t, isDebouncedForTeam("normalized", "確認した"))
+	assert.True(t, isDebouncedForTeam("normalized", "確認しました。"))
+
+	// デフォルトの設定では別の内容とみなされる
+	assert.False(t, isDebouncedForTeam("work", "確認した"))
+	assert.False(t, isDebouncedForTeam("work", "確認しました。"))
+}
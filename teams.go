@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TeamNames は設定されているプロファイル名を名前順に返す
+func (c EsaConfig) TeamNames() []string {
+	names := make([]string, 0, len(c.Teams))
+	for name := range c.Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTeam はツールで指定されたteamを使用するプロファイル名に解決する
+// 共通の設定（プロファイルなし）を使う場合は空文字を返す
+func (c EsaConfig) ResolveTeam(team string) (string, error) {
+	if team == "" {
+		team = c.DefaultTeam
+	}
+	if team == "" {
+		switch {
+		case len(c.Teams) == 0 || c.TeamName != "":
+			return "", nil
+		case len(c.Teams) == 1:
+			return c.TeamNames()[0], nil
+		default:
+			return "", newToolError(ErrInvalidParams, "teamを指定してください（%s）", strings.Join(c.TeamNames(), ", "))
+		}
+	}
+
+	if _, ok := c.Teams[team]; ok {
+		return team, nil
+	}
+	// 共通の設定のチーム名を指定した場合
+	if team == c.TeamName {
+		return "", nil
+	}
+	if len(c.Teams) == 0 {
+		return "", newToolError(ErrInvalidParams, "チーム%sは設定されていません", team)
+	}
+	return "", newToolError(ErrInvalidParams, "チーム%sは設定されていません（%s）", team, strings.Join(c.TeamNames(), ", "))
+}
+
+// Profile は指定したプロファイルの設定を共通の設定に重ねたEsaConfigを返す
+// nameが空の場合は共通の設定をそのまま返す
+func (c EsaConfig) Profile(name string) (EsaConfig, error) {
+	config := c
+	config.Teams = nil
+	config.DefaultTeam = ""
+	if name == "" {
+		return config, nil
+	}
+
+	profile, ok := c.Teams[name]
+	if !ok {
+		return EsaConfig{}, fmt.Errorf("チーム%sは設定されていません", name)
+	}
+
+	config.TeamName = name
+	if profile.TeamName != "" {
+		config.TeamName = profile.TeamName
+	}
+	if profile.AccessToken != "" {
+		config.AccessToken = profile.AccessToken
+	}
+	if profile.CategoryTemplate != "" {
+		config.CategoryTemplate = profile.CategoryTemplate
+	}
+	if profile.TitleTemplate != "" {
+		config.TitleTemplate = profile.TitleTemplate
+	}
+	// 本文のテンプレートはどれか1つを指定するため、プロファイルで指定した場合は共通の設定をまとめて置き換える
+	if profile.BodyTemplate != "" || profile.TemplatePostID != 0 || profile.TemplateCategory != "" {
+		config.BodyTemplate = profile.BodyTemplate
+		config.TemplatePostID = profile.TemplatePostID
+		config.TemplateCategory = profile.TemplateCategory
+	}
+	if profile.Tags != nil {
+		config.Tags = profile.Tags
+	}
+	if profile.ScreenName != "" {
+		config.ScreenName = profile.ScreenName
+	}
+	if profile.EntryPlacement != "" {
+		config.EntryPlacement = profile.EntryPlacement
+	}
+	if profile.Debounce != nil {
+		config.Debounce = profile.Debounce.apply(config.Debounce)
+	}
+	return config, nil
+}
+
+// teamEnvName はプロファイルごとの環境変数名（ESA_<PROFILE>_<KEY>）を返す
+// プロファイル名は大文字にし、英数字以外は_に置き換える
+func teamEnvName(team, key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, team)
+	return "ESA_" + name + "_" + key
+}
+
+// applyTeamsEnv はESA_TEAMSに列挙されたプロファイルの環境変数を上書きする
+func applyTeamsEnv(config *EsaConfig, getenv func(string) string) error {
+	if value := getenv("ESA_DEFAULT_TEAM"); value != "" {
+		config.DefaultTeam = value
+	}
+
+	for _, team := range splitList(getenv("ESA_TEAMS")) {
+		if config.Teams == nil {
+			config.Teams = make(map[string]TeamProfile)
+		}
+		profile := config.Teams[team]
+
+		fields := map[string]*string{
+			"TEAM_NAME":         &profile.TeamName,
+			"ACCESS_TOKEN":      &profile.AccessToken,
+			"CATEGORY_TEMPLATE": &profile.CategoryTemplate,
+			"TITLE_TEMPLATE":    &profile.TitleTemplate,
+			"BODY_TEMPLATE":     &profile.BodyTemplate,
+			"TEMPLATE_CATEGORY": &profile.TemplateCategory,
+			"SCREEN_NAME":       &profile.ScreenName,
+			"ENTRY_PLACEMENT":   &profile.EntryPlacement,
+		}
+		for key, field := range fields {
+			if value := getenv(teamEnvName(team, key)); value != "" {
+				*field = value
+			}
+		}
+		if value := getenv(teamEnvName(team, "TAGS")); value != "" {
+			profile.Tags = splitList(value)
+		}
+		if key := teamEnvName(team, "TEMPLATE_POST_ID"); getenv(key) != "" {
+			id, err := strconv.Atoi(getenv(key))
+			if err != nil {
+				return fmt.Errorf("環境変数%sの値が不正です: %w", key, err)
+			}
+			profile.TemplatePostID = id
+		}
+
+		config.Teams[team] = profile
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ptr はテスト用に値へのポインタを返す
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEsaConfig_ResolveTeam(t *testing.T) {
+	teams := map[string]TeamProfile{
+		"personal": {AccessToken: "personal-token"},
+		"work":     {TeamName: "company", AccessToken: "work-token"},
+	}
+
+	tests := []struct {
+		name          string
+		config        EsaConfig
+		team          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "プロファイルなし",
+			config:   EsaConfig{TeamName: "single"},
+			expected: "",
+		},
+		{
+			name:     "プロファイルなしで共通のチーム名を指定",
+			config:   EsaConfig{TeamName: "single"},
+			team:     "single",
+			expected: "",
+		},
+		{
+			name:          "プロファイルなしで未知のチーム",
+			config:        EsaConfig{TeamName: "single"},
+			team:          "other",
+			expectedError: "チームotherは設定されていません",
+		},
+		{
+			name:     "プロファイルを指定",
+			config:   EsaConfig{Teams: teams},
+			team:     "work",
+			expected: "work",
+		},
+		{
+			name:     "省略時はdefault_team",
+			config:   EsaConfig{Teams: teams, DefaultTeam: "personal"},
+			expected: "personal",
+		},
+		{
+			name:     "省略時は共通の設定のチーム",
+			config:   EsaConfig{TeamName: "single", Teams: teams},
+			expected: "",
+		},
+		{
+			name:     "プロファイルが1つなら省略できる",
+			config:   EsaConfig{Teams: map[string]TeamProfile{"work": {}}},
+			expected: "work",
+		},
+		{
+			name:          "プロファイルが複数あると省略できない",
+			config:        EsaConfig{Teams: teams},
+			expectedError: "teamを指定してください（personal, work）",
+		},
+		{
+			name:          "未知のプロファイル",
+			config:        EsaConfig{Teams: teams},
+			team:          "hobby",
+			expectedError: "チームhobbyは設定されていません（personal, work）",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team, err := tt.config.ResolveTeam(tt.team)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, team)
+		})
+	}
+}
+
+func TestEsaConfig_Profile(t *testing.T) {
+	config := DefaultConfig()
+	config.TeamName = "base"
+	config.AccessToken = "base-token"
+	config.Tags = []string{"日報"}
+	config.ScreenName = "base_user"
+	config.Teams = map[string]TeamProfile{
+		"work": {
+			TeamName:         "company",
+			AccessToken:      "work-token",
+			CategoryTemplate: "times/{{.ScreenName}}/{{.Year}}/{{.Month}}/{{.Day}}",
+			EntryPlacement:   entryPlacementAppend,
+			BodyTemplate:     "## やったこと\n\n## 明日やること\n",
+			Debounce:         &TeamDebounceConfig{Duration: ptr(time.Minute), SimilarityThreshold: ptr(0.5)},
+		},
+		"personal":  {},
+		"templated": {TemplatePostID: 42},
+	}
+	config.BodyTemplate = "## 共通の見出し\n"
+	config.DefaultTeam = "work"
+
+	t.Run("プロファイルの設定で上書きする", func(t *testing.T) {
+		profile, err := config.Profile("work")
+		require.NoError(t, err)
+		assert.Equal(t, "company", profile.TeamName)
+		assert.Equal(t, "work-token", profile.AccessToken)
+		assert.Equal(t, "times/{{.ScreenName}}/{{.Year}}/{{.Month}}/{{.Day}}", profile.CategoryTemplate)
+		assert.Equal(t, DebounceConfig{Duration: time.Minute, SimilarityThreshold: 0.5}, profile.Debounce)
+		assert.Equal(t, entryPlacementAppend, profile.EntryPlacement)
+		assert.Equal(t, "## やったこと\n\n## 明日やること\n", profile.BodyTemplate)
+		// 空の項目は共通の設定を引き継ぐ
+		assert.Equal(t, defaultTitleTemplate, profile.TitleTemplate)
+		assert.Equal(t, []string{"日報"}, profile.Tags)
+		assert.Equal(t, "base_user", profile.ScreenName)
+		assert.Nil(t, profile.Teams)
+		assert.Empty(t, profile.DefaultTeam)
+	})
+
+	t.Run("team_nameが空ならプロファイル名を使う", func(t *testing.T) {
+		profile, err := config.Profile("personal")
+		require.NoError(t, err)
+		assert.Equal(t, "personal", profile.TeamName)
+		assert.Equal(t, "base-token", profile.AccessToken)
+		assert.Equal(t, defaultDebounceConfig, profile.Debounce)
+		assert.Empty(t, profile.EntryPlacement)
+		assert.Equal(t, "## 共通の見出し\n", profile.BodyTemplate)
+	})
+
+	t.Run("本文のテンプレートはまとめて置き換える", func(t *testing.T) {
+		profile, err := config.Profile("templated")
+		require.NoError(t, err)
+		assert.Equal(t, 42, profile.TemplatePostID)
+		assert.Empty(t, profile.BodyTemplate)
+		assert.NoError(t, profile.Validate())
+	})
+
+	t.Run("空なら共通の設定", func(t *testing.T) {
+		profile, err := config.Profile("")
+		require.NoError(t, err)
+		assert.Equal(t, "base", profile.TeamName)
+		assert.Equal(t, defaultCategoryTemplate, profile.CategoryTemplate)
+	})
+
+	t.Run("未知のプロファイル", func(t *testing.T) {
+		_, err := config.Profile("hobby")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadConfig_Teams(t *testing.T) {
+	t.Run("環境変数でプロファイルを設定", func(t *testing.T) {
+		env := envMap(map[string]string{
+			"ESA_TEAMS":                      "work, my-team",
+			"ESA_DEFAULT_TEAM":               "work",
+			"ESA_WORK_TEAM_NAME":             "company",
+			"ESA_WORK_ACCESS_TOKEN":          "work-token",
+			"ESA_WORK_TAGS":                  "日報, 仕事",
+			"ESA_MY_TEAM_ACCESS_TOKEN":       "personal-token",
+			"ESA_MY_TEAM_CATEGORY_TEMPLATE":  "times/{{.Date}}",
+			"ESA_PERSONAL_ACCESS_TOKEN":      "ignored",
+			"ESA_WORK_UNKNOWN_CONFIGURATION": "ignored",
+		})
+
+		config, err := loadConfig(nil, env)
+		require.NoError(t, err)
+		assert.Equal(t, "work", config.DefaultTeam)
+		assert.Equal(t, map[string]TeamProfile{
+			"work": {
+				TeamName:    "company",
+				AccessToken: "work-token",
+				Tags:        []string{"日報", "仕事"},
+			},
+			"my-team": {
+				AccessToken:      "personal-token",
+				CategoryTemplate: "times/{{.Date}}",
+			},
+		}, config.Teams)
+	})
+
+	t.Run("設定ファイルのプロファイルを環境変数で上書き", func(t *testing.T) {
+		path := writeConfigFile(t, `
+default_team: work
+teams:
+  work:
+    team_name: company
+    access_token: file-token
+    debounce:
+      duration: 1m
+      similarity_threshold: 0.5
+  personal:
+    access_token: personal-token
+`)
+		env := envMap(map[string]string{
+			"ESA_TEAMS":             "work",
+			"ESA_WORK_ACCESS_TOKEN": "env-token",
+		})
+
+		config, err := loadConfig([]string{"-config", path, "-default-team", "personal"}, env)
+		require.NoError(t, err)
+		assert.Equal(t, "personal", config.DefaultTeam)
+		assert.Equal(t, "company", config.Teams["work"].TeamName)
+		assert.Equal(t, "env-token", config.Teams["work"].AccessToken)
+		assert.Equal(t, &TeamDebounceConfig{Duration: ptr(time.Minute), SimilarityThreshold: ptr(0.5)}, config.Teams["work"].Debounce)
+		assert.Equal(t, "personal-token", config.Teams["personal"].AccessToken)
+	})
+
+	t.Run("プロファイルで指定しなかったデバウンスの項目は共通の設定を引き継ぐ", func(t *testing.T) {
+		path := writeConfigFile(t, `
+debounce:
+  duration: 5m
+  similarity_threshold: 0.8
+teams:
+  work:
+    debounce:
+      duration: 1m
+`)
+		config, err := loadConfig([]string{"-config", path}, envMap(map[string]string{}))
+		require.NoError(t, err)
+
+		profile, err := config.Profile("work")
+		require.NoError(t, err)
+		assert.Equal(t, DebounceConfig{Duration: time.Minute, SimilarityThreshold: 0.8}, profile.Debounce)
+
+		// しきい値が0にならないため、違う内容の投稿は拒否されない
+		resetDebounce()
+		SetTeamDebounceConfig("duration-only", profile.Debounce)
+		defer func() {
+			debounceMutex.Lock()
+			delete(teamDebounceConfigs, "duration-only")
+			debounceMutex.Unlock()
+		}()
+		assert.False(t, isDebouncedForTeam("duration-only", "朝会に参加した"))
+		assert.False(t, isDebouncedForTeam("duration-only", "設計レビューの資料を書いた"))
+		assert.True(t, isDebouncedForTeam("duration-only", "設計レビューの資料を書いた"))
+	})
+
+	t.Run("default_teamが存在しない場合はエラー", func(t *testing.T) {
+		_, err := loadConfig(nil, envMap(map[string]string{
+			"ESA_TEAMS":        "work",
+			"ESA_DEFAULT_TEAM": "personal",
+		}))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "default_teamのチームpersonalは設定されていません")
+	})
+
+	t.Run("プロファイルのテンプレートも検証する", func(t *testing.T) {
+		_, err := loadConfig(nil, envMap(map[string]string{
+			"ESA_TEAMS":                  "work",
+			"ESA_WORK_CATEGORY_TEMPLATE": "日報/{{.Year",
+		}))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "teams.work: カテゴリーのテンプレートの解析に失敗")
+	})
+
+	t.Run("共通の設定の誤りはプロファイルの数だけ繰り返さない", func(t *testing.T) {
+		_, err := loadConfig(nil, envMap(map[string]string{
+			"ESA_TEAMS":                    "work,personal",
+			"ESA_CATEGORY_TEMPLATE":        "日報/{{.Year",
+			"ESA_PERSONAL_ENTRY_PLACEMENT": "bottom",
+		}))
+		assert.Error(t, err)
+		assert.Equal(t, 1, strings.Count(err.Error(), "カテゴリーのテンプレートの解析に失敗"))
+		assert.NotContains(t, err.Error(), "teams.work")
+		assert.Contains(t, err.Error(), "teams.personal: entry_placement")
+	})
+}
+
+func TestDefaultHandlerFactory_CreateEsaClient(t *testing.T) {
+	factory := &DefaultHandlerFactory{Config: EsaConfig{
+		TeamName:    "base",
+		AccessToken: "base-token",
+		Teams: map[string]TeamProfile{
+			"work":     {AccessToken: "work-token"},
+			"personal": {TeamName: ""},
+		},
+	}}
+
+	client, err := factory.CreateEsaClient("work")
+	require.NoError(t, err)
+	assert.Equal(t, "work", client.(*EsaClient).config.TeamName)
+	assert.Equal(t, "work-token", client.(*EsaClient).config.AccessToken)
+
+	client, err = factory.CreateEsaClient("")
+	require.NoError(t, err)
+	assert.Equal(t, "base", client.(*EsaClient).config.TeamName)
+
+	_, err = factory.CreateEsaClient("hobby")
+	assert.Error(t, err)
+}
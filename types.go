@@ -3,10 +3,132 @@ package main
 type TimesEsaPostRequest struct {
 	Text            string `json:"text"`
 	ConfirmedByUser bool   `json:"confirmed_by_user"`
+	Team            string `json:"team,omitempty"`
+	// 過去の日報に書く場合の日付（YYYY-MM-DD形式）と時刻（HH:MM形式）
+	Date string `json:"date,omitempty"`
+	Time string `json:"time,omitempty"`
+	// エントリを書く日報の見出し（本文のテンプレートの見出しなど）
+	Section string `json:"section,omitempty"`
 }
 
 type TimesEsaPostResponse struct {
-	Success bool    `json:"success"`
-	Message string  `json:"message"`
-	Post    EsaPost `json:"post"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Post      EsaPost     `json:"post"`
+	RateLimit *RateLimit  `json:"rate_limit,omitempty"`
+	Queued    *OutboxItem `json:"queued,omitempty"`
+}
+
+type TimesEsaReadRequest struct {
+	Date string `json:"date,omitempty"`
+	Team string `json:"team,omitempty"`
+}
+
+type TimesEsaReadResponse struct {
+	Success  bool               `json:"success"`
+	Message  string             `json:"message"`
+	Date     string             `json:"date"`
+	Category string             `json:"category"`
+	Entries  []DailyReportEntry `json:"entries"`
+}
+
+type TimesEsaEditEntryRequest struct {
+	Date            string `json:"date,omitempty"`
+	AnchorID        string `json:"anchor_id,omitempty"`
+	Index           int    `json:"index,omitempty"`
+	Text            string `json:"text"`
+	ConfirmedByUser bool   `json:"confirmed_by_user"`
+	Team            string `json:"team,omitempty"`
+}
+
+type TimesEsaDeleteEntryRequest struct {
+	Date            string `json:"date,omitempty"`
+	AnchorID        string `json:"anchor_id,omitempty"`
+	Index           int    `json:"index,omitempty"`
+	ConfirmedByUser bool   `json:"confirmed_by_user"`
+	Team            string `json:"team,omitempty"`
+}
+
+type TimesEsaEntryResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Entry   DailyReportEntry `json:"entry"`
+	Post    EsaPost          `json:"post"`
+}
+
+type EsaSearchRequest struct {
+	Keywords       []string `json:"keywords,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	User           string   `json:"user,omitempty"`
+	CategoryPrefix string   `json:"category_prefix,omitempty"`
+	DateField      string   `json:"date_field,omitempty"`
+	DateFrom       string   `json:"date_from,omitempty"`
+	DateTo         string   `json:"date_to,omitempty"`
+	WIP            *bool    `json:"wip,omitempty"`
+	Starred        *bool    `json:"starred,omitempty"`
+	Sort           string   `json:"sort,omitempty"`
+	Order          string   `json:"order,omitempty"`
+	Page           int      `json:"page,omitempty"`
+	PerPage        int      `json:"per_page,omitempty"`
+	Team           string   `json:"team,omitempty"`
+}
+
+type EsaSearchResponse struct {
+	Success    bool                `json:"success"`
+	Message    string              `json:"message"`
+	TotalCount int                 `json:"total_count"`
+	Posts      []EsaSearchPostItem `json:"posts"`
+}
+
+type EsaSearchPostItem struct {
+	Number   int      `json:"number"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	Excerpt  string   `json:"excerpt"`
+}
+
+type TimesEsaDuplicatesRequest struct {
+	Date            string `json:"date,omitempty"`
+	Repair          bool   `json:"repair,omitempty"`
+	Keep            string `json:"keep,omitempty"`
+	ConfirmedByUser bool   `json:"confirmed_by_user,omitempty"`
+	Team            string `json:"team,omitempty"`
+}
+
+type TimesEsaDuplicatesResponse struct {
+	Success  bool                `json:"success"`
+	Message  string              `json:"message"`
+	Date     string              `json:"date"`
+	Category string              `json:"category"`
+	Posts    []EsaSearchPostItem `json:"posts"`
+	Post     *EsaPost            `json:"post,omitempty"`
+	Archived []int               `json:"archived,omitempty"`
+}
+
+type TimesEsaOutboxRequest struct {
+	Action          string   `json:"action,omitempty"`
+	IDs             []string `json:"ids,omitempty"`
+	ConfirmedByUser bool     `json:"confirmed_by_user,omitempty"`
+}
+
+type TimesEsaOutboxResponse struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Items   []OutboxItem `json:"items"`
+	Posted  []OutboxItem `json:"posted,omitempty"`
+	Dropped []OutboxItem `json:"dropped,omitempty"`
+}
+
+type ToolErrorResponse struct {
+	Success bool            `json:"success"`
+	Error   ToolErrorDetail `json:"error"`
+}
+
+type ToolErrorDetail struct {
+	Code              string `json:"code"`
+	Message           string `json:"message"`
+	HTTPStatus        int    `json:"http_status,omitempty"`
+	Retryable         bool   `json:"retryable"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
 }
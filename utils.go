@@ -2,89 +2,154 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
 )
 
+// Clock は現在時刻を取得するためのインターフェース
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock はシステムの現在時刻を返すClock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// defaultClock はハンドラーが投稿時刻を決めるために使うClock
+var defaultClock Clock = systemClock{}
+
 // debounce設定を管理する構造体
 type DebounceConfig struct {
-	Duration            time.Duration // debounceする時間
-	SimilarityThreshold float64       // 類似度のしきい値（0.0〜1.0）
+	Duration            time.Duration `yaml:"duration"`             // debounceする時間
+	SimilarityThreshold float64       `yaml:"similarity_threshold"` // 類似度のしきい値（0.0〜1.0）
+	Remote              bool          `yaml:"remote"`               // 既存の日報のエントリとも比較するかどうか
+	Metric              string        `yaml:"metric"`               // 類似度の計算方法（空ならlevenshtein）
+}
+
+// defaultDebounceConfig はデバウンス設定のデフォルト値
+var defaultDebounceConfig = DebounceConfig{
+	Duration:            300 * time.Second,
+	SimilarityThreshold: 0.9, // デフォルトは90%以上の類似度でデバウンス
 }
 
 // debounce用の構造体
 type debounceEntry struct {
-	text      string
-	timestamp time.Time
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
-// debounceを管理するマップとミューテックス
+// debounceを管理するストアとミューテックス
+// 履歴はチーム（プロファイル名）ごとに分け、チームをまたいだ同じ内容の投稿は拒否しない
 var (
-	debounceMap    = make(map[string]debounceEntry)
-	debounceMutex  sync.Mutex
-	debounceConfig = DebounceConfig{
-		Duration:            300 * time.Second,
-		SimilarityThreshold: 0.9, // デフォルトは90%以上の類似度でデバウンス
-	}
+	debounceStore       DebounceStore = newMemoryDebounceStore()
+	debounceMutex       sync.Mutex
+	debounceConfig      = defaultDebounceConfig
+	teamDebounceConfigs = make(map[string]DebounceConfig)
+
+	// fallbackDebounceStore はdebounceStoreが使えない場合に代わりに使うストア
+	fallbackDebounceStore = newMemoryDebounceStore()
 )
 
 // レーベンシュタイン距離を計算する関数
 // s1, s2の2つの文字列間の編集距離を返す
 // マルチバイト文字（日本語など）を正しく処理するためにrune単位で計算
 func levenshteinDistance(s1, s2 string) int {
-	// 文字列をrune（Unicode文字）のスライスに変換
-	runes1 := []rune(s1)
-	runes2 := []rune(s2)
+	// 文字列が同一なら距離0
+	if s1 == s2 {
+		return 0
+	}
 
-	s1Len := len(runes1)
-	s2Len := len(runes2)
+	// 文字列をrune（Unicode文字）のスライスに変換
+	runes1, runes2 := trimCommonAffix([]rune(s1), []rune(s2))
 
 	// 最適化: どちらかが空文字列なら、もう一方の長さが距離
-	if s1Len == 0 {
-		return s2Len
+	if len(runes1) == 0 {
+		return len(runes2)
 	}
-	if s2Len == 0 {
-		return s1Len
+	if len(runes2) == 0 {
+		return len(runes1)
 	}
 
-	// 文字列が同一なら距離0
-	if s1 == s2 {
-		return 0
+	// 上限なしで計算する（距離は長い方の文字数を超えない）
+	return boundedLevenshteinDistance(runes1, runes2, max(len(runes1), len(runes2)))
+}
+
+// trimCommonAffix は2つのruneスライスから共通の接頭辞と接尾辞を取り除く
+// 共通部分は編集距離に影響しないため、追記や一部の修正だけの投稿では比較する範囲が大きく減る
+func trimCommonAffix(a, b []rune) ([]rune, []rune) {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
 	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	return a, b
+}
 
-	// 2次元配列の初期化
-	matrix := make([][]int, s1Len+1)
-	for i := range matrix {
-		matrix[i] = make([]int, s2Len+1)
+// boundedLevenshteinDistance は編集距離がlimit以下ならその値を、limitを超える場合はlimit+1を返す
+// 対角線からlimit以内の帯だけを、対角線ごとに到達できる最も先の行（2本分の配列）として計算する
+// 一致する文字はまとめて読み飛ばすため、ほぼ同じテキストは編集距離の2乗程度の計算で済み、limitを超えた時点で打ち切る
+func boundedLevenshteinDistance(a, b []rune, limit int) int {
+	// aを短い方にそろえる
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	n, m := len(a), len(b)
+	if m-n > limit {
+		return limit + 1
 	}
 
-	// 初期値設定
-	for i := 0; i <= s1Len; i++ {
-		matrix[i][0] = i
+	// 対角線k（j = i + k）上で一致する文字を読み飛ばした先の行を返す
+	slide := func(i, k int) int {
+		for i < n && i+k < m && a[i] == b[i+k] {
+			i++
+		}
+		return i
 	}
-	for j := 0; j <= s2Len; j++ {
-		matrix[0][j] = j
+
+	// 最後のセル(n, m)がある対角線
+	target := m - n
+	if slide(0, 0) == n && target == 0 {
+		return 0
 	}
 
-	// 距離計算
-	for i := 1; i <= s1Len; i++ {
-		for j := 1; j <= s2Len; j++ {
-			cost := 1
-			if runes1[i-1] == runes2[j-1] {
-				cost = 0
-			}
-			// Go 1.21の標準min関数を使用
-			matrix[i][j] = min(
-				matrix[i-1][j]+1,      // 削除
-				matrix[i][j-1]+1,      // 挿入
-				matrix[i-1][j-1]+cost, // 置換または一致
+	// furthest[k+offset]はd回の編集で対角線k上で到達できる最も先の行
+	// 帯の外側は到達不能な値にしておき、前の行から参照されても選ばれないようにする
+	offset := limit + 1
+	unreachable := -(n + m + 2)
+	prev := make([]int, 2*limit+3)
+	curr := make([]int, 2*limit+3)
+	for i := range prev {
+		prev[i], curr[i] = unreachable, unreachable
+	}
+	prev[offset] = slide(0, 0)
+
+	for d := 1; d <= limit; d++ {
+		for k := max(-d, -n); k <= min(d, m); k++ {
+			i := max(
+				prev[offset+k]+1,   // 置換
+				prev[offset+k-1],   // 挿入
+				prev[offset+k+1]+1, // 削除
 			)
+			i = min(i, n, m-k)
+			i = slide(i, k)
+			curr[offset+k] = i
+			if k == target && i >= n {
+				return d
+			}
 		}
+		prev, curr = curr, prev
 	}
 
-	return matrix[s1Len][s2Len]
+	return limit + 1
 }
 
 // テキスト類似度を計算する関数
@@ -120,11 +185,220 @@ func textSimilarity(s1, s2 string) float64 {
 	return 1.0 - float64(distance)/float64(maxAllowedDistance)
 }
 
+// similarityAtLeast はtextSimilarity(s1, s2) >= thresholdかどうかを判定する
+func similarityAtLeast(s1, s2 string, threshold float64) bool {
+	return newSimilarityMatcher(s1, threshold).match(s2)
+}
+
+// similarityMatcher は1つのテキストを複数のテキストと比較し、類似度がしきい値以上かを判定する
+// しきい値から許される編集距離の上限を求め、文字数の差と3文字組の出現頻度で明らかに異なるものを先に除外してから、
+// 上限までの範囲だけ編集距離を計算する（長いテキスト同士でも全体の行列を作らない）
+// 比較元のテキストの出現頻度は最初に必要になった1回だけ数え、テキストごとの文字数と3文字組はcachedTextProfileで使い回す
+type similarityMatcher struct {
+	text      string
+	threshold float64
+
+	// 比較元のテキストの文字数と3文字組（必要になった時点で求める）
+	self *textProfile
+
+	// 比較元のテキストの3文字組の出現頻度と、その合計（必要になった時点で計算する）
+	counts    []int32
+	countsSum int
+
+	// 編集距離を計算するときの比較元と比較する相手のrune（相手の分は比較のたびに上書きして使い回す）
+	runes      []rune
+	otherRunes []rune
+}
+
+// newSimilarityMatcher はtextと比較するsimilarityMatcherを返す
+func newSimilarityMatcher(text string, threshold float64) *similarityMatcher {
+	return &similarityMatcher{text: text, threshold: threshold}
+}
+
+// profileGramSize は出現頻度を数える文字組の長さ
+const profileGramSize = 3
+
+// match はtextSimilarity(text, other) >= thresholdかどうかを判定する
+func (m *similarityMatcher) match(other string) bool {
+	if m.text == other || m.threshold <= 0 {
+		return true
+	}
+	if len(m.text) == 0 || len(other) == 0 {
+		return false
+	}
+
+	if m.self == nil {
+		m.self = cachedTextProfile(m.text)
+	}
+	otherProfile := cachedTextProfile(other)
+	maxLen := max(m.self.runeCount, otherProfile.runeCount)
+
+	// 類似度がしきい値以上になる編集距離の上限（浮動小数点の誤差の分だけ広めにとり、最後に正確に判定する）
+	limit := int(math.Floor((1-m.threshold)*float64(maxLen) + 1e-9))
+	if limit >= maxLen {
+		limit = maxLen - 1
+	}
+	if limit < 0 {
+		return false
+	}
+
+	// 文字数の差は編集距離の下限
+	if abs(m.self.runeCount-otherProfile.runeCount) > limit {
+		return false
+	}
+
+	// 3文字組の出現頻度の差も編集距離の下限になる（1回の編集で変わる3文字組は6個まで）
+	if m.profileDistanceExceeds(otherProfile, 2*profileGramSize*limit) {
+		return false
+	}
+
+	// 共通の接頭辞・接尾辞は距離に影響しない
+	if m.runes == nil {
+		m.runes = []rune(m.text)
+	}
+	m.otherRunes = appendRunes(m.otherRunes[:0], other)
+	a, b := trimCommonAffix(m.runes, m.otherRunes)
+	distance := boundedLevenshteinDistance(a, b, limit)
+	if distance > limit || distance >= maxLen {
+		return false
+	}
+	return 1.0-float64(distance)/float64(maxLen) >= m.threshold
+}
+
+// profileDistanceExceeds は比較元のテキストとotherの3文字組の出現頻度の差（L1距離）がboundを超えるかどうかを返す
+// 頻度はハッシュで振り分けたバケットで数える。衝突しても差は小さくなるだけなので、編集距離の下限として使える
+// 残りの3文字組をすべて使っても差がbound以下にならないと分かった時点で打ち切る
+func (m *similarityMatcher) profileDistanceExceeds(other *textProfile, bound int) bool {
+	if m.counts == nil {
+		// テキストが長いほどバケットを増やして衝突を減らす
+		buckets := 64
+		for buckets < len(m.self.grams) && buckets < 1<<16 {
+			buckets *= 2
+		}
+		mask := uint32(buckets - 1)
+		m.counts = make([]int32, buckets)
+		for _, h := range m.self.grams {
+			m.counts[h&mask]++
+		}
+		m.countsSum = len(m.self.grams)
+	}
+
+	// countsからotherの出現回数を引いて差を求め、最後に引いた分を戻す
+	mask := uint32(len(m.counts) - 1)
+	distance := m.countsSum
+	used := len(other.grams)
+	exceeds := false
+	for i, h := range other.grams {
+		h &= mask
+		if m.counts[h] > 0 {
+			distance--
+		} else {
+			distance++
+		}
+		m.counts[h]--
+
+		// 残りの3文字組で差が1つずつ縮んでもboundを超える
+		if distance-(len(other.grams)-i-1) > bound {
+			used, exceeds = i+1, true
+			break
+		}
+	}
+	for _, h := range other.grams[:used] {
+		m.counts[h&mask]++
+	}
+	return exceeds || distance > bound
+}
+
+// textProfile はテキストの文字数と、3文字組ごとのハッシュ値
+type textProfile struct {
+	runeCount int
+	grams     []uint32
+}
+
+// textProfileCacheSize は文字数と3文字組を覚えておくテキストの数
+// デバウンスの履歴は投稿のたびに同じテキストと比較するため、テキストごとに1回だけ計算すれば済む
+const textProfileCacheSize = 128
+
+var (
+	textProfileMutex sync.Mutex
+	textProfiles     = make(map[string]*textProfile)
+)
+
+// cachedTextProfile はtextの文字数と3文字組を返す（覚えていなければ計算する）
+func cachedTextProfile(text string) *textProfile {
+	textProfileMutex.Lock()
+	defer textProfileMutex.Unlock()
+
+	if p, ok := textProfiles[text]; ok {
+		return p
+	}
+	if len(textProfiles) >= textProfileCacheSize {
+		clear(textProfiles)
+	}
+	p := newTextProfile(text)
+	textProfiles[text] = p
+	return p
+}
+
+// newTextProfile はtextの文字数と3文字組を求める
+func newTextProfile(text string) *textProfile {
+	// 文字数はバイト数を超えないので、i文字目で終わる3文字組のハッシュ値をgrams[i]に書いてから先頭の2つを除く
+	grams := make([]uint32, len(text))
+	n := 0
+	var r0, r1 rune
+	for _, r := range text {
+		grams[n] = ngramHash([profileGramSize]rune{r0, r1, r})
+		n++
+		r0, r1 = r1, r
+	}
+
+	p := &textProfile{runeCount: n}
+	if n >= profileGramSize {
+		p.grams = grams[profileGramSize-1 : n]
+	}
+	return p
+}
+
+// appendRunes はsのruneをdstに追加したスライスを返す
+func appendRunes(dst []rune, s string) []rune {
+	for _, r := range s {
+		dst = append(dst, r)
+	}
+	return dst
+}
+
+// ngramHash は3文字組のハッシュ値を返す（FNV-1a）
+func ngramHash(gram [profileGramSize]rune) uint32 {
+	h := uint32(2166136261)
+	for _, r := range gram {
+		h ^= uint32(r)
+		h *= 16777619
+	}
+	return h
+}
+
+// abs は整数の絶対値を返す
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
 // テスト用にdebounceをリセットする関数
 func resetDebounce() {
 	debounceMutex.Lock()
 	defer debounceMutex.Unlock()
-	debounceMap = make(map[string]debounceEntry)
+	debounceStore = newMemoryDebounceStore()
+	fallbackDebounceStore = newMemoryDebounceStore()
+}
+
+// SetDebounceStore はデバウンスの履歴を保持するストアを変更する関数
+func SetDebounceStore(store DebounceStore) {
+	debounceMutex.Lock()
+	defer debounceMutex.Unlock()
+
+	debounceStore = store
 }
 
 // SetDebounceConfig はデバウンス設定を変更する関数
@@ -136,34 +410,132 @@ func SetDebounceConfig(duration time.Duration, similarityThreshold float64) {
 	debounceConfig.SimilarityThreshold = similarityThreshold
 }
 
+// SetRemoteDebounce は既存の日報のエントリとも比較するかどうかを変更する関数
+func SetRemoteDebounce(enabled bool) {
+	debounceMutex.Lock()
+	defer debounceMutex.Unlock()
+
+	debounceConfig.Remote = enabled
+}
+
+// SetDebounceMetric は類似度の計算方法を変更する関数
+func SetDebounceMetric(metric string) {
+	debounceMutex.Lock()
+	defer debounceMutex.Unlock()
+
+	debounceConfig.Metric = metric
+}
+
+// SetTeamDebounceConfig はチームごとのデバウンス設定を変更する関数
+func SetTeamDebounceConfig(team string, config DebounceConfig) {
+	debounceMutex.Lock()
+	defer debounceMutex.Unlock()
+
+	teamDebounceConfigs[team] = config
+}
+
+// debounceConfigFor はチームに適用するデバウンス設定を返す（呼び出し側でロックすること）
+func debounceConfigFor(team string) DebounceConfig {
+	if config, ok := teamDebounceConfigs[team]; ok {
+		return config
+	}
+	return debounceConfig
+}
+
+// DebounceDuration はチームに適用するデバウンスの時間を返す
+func DebounceDuration(team string) time.Duration {
+	debounceMutex.Lock()
+	defer debounceMutex.Unlock()
+	return debounceConfigFor(team).Duration
+}
+
+// isRemoteDuplicate は既存の日報のエントリのうちデバウンスの時間内に書かれたものとtextを比べ、同じ内容とみなせるかを返す
+// 他の端末やブラウザから投稿された内容との重複を防ぐため、既存の日報の本文bodyMdを使う
+// エントリの時刻は投稿時刻nowと同じ日の時刻として扱い、見つかったエントリを返す
+func isRemoteDuplicate(team, bodyMd, text string, now time.Time) (DailyReportEntry, bool) {
+	debounceMutex.Lock()
+	config := debounceConfigFor(team)
+	debounceMutex.Unlock()
+
+	if !config.Remote {
+		return DailyReportEntry{}, false
+	}
+
+	text = strings.TrimSpace(text)
+	match := similarityMetricFor(config).Matcher(text, config.SimilarityThreshold)
+	for _, entry := range ParseDailyReportEntries(bodyMd) {
+		entryTime, err := time.ParseInLocation("15:04", entry.Time, now.Location())
+		if err != nil {
+			continue
+		}
+		entryTime = time.Date(now.Year(), now.Month(), now.Day(), entryTime.Hour(), entryTime.Minute(), 0, 0, now.Location())
+		// 日付が変わる時刻が0時より後の場合、0時より前に書かれたエントリは前日の時刻になる
+		if entryTime.After(now) {
+			entryTime = entryTime.AddDate(0, 0, -1)
+		}
+		// 時刻は分単位なので、同じ分のエントリは経過時間0として扱う
+		if now.Sub(entryTime) >= config.Duration+time.Minute {
+			continue
+		}
+		if entry.Text == text {
+			return entry, true
+		}
+		if len(text) > 1 && len(entry.Text) > 1 && match(entry.Text) {
+			return entry, true
+		}
+	}
+	return DailyReportEntry{}, false
+}
+
 // isDebounced は指定されたテキストが短時間内に処理済みかチェックする
 // テキストの完全一致だけでなく、高い類似度を持つテキストもデバウンスする
 func isDebounced(text string) bool {
-	debounceMutex.Lock()
-	defer debounceMutex.Unlock()
+	return isDebouncedForTeam("", text)
+}
 
+// isDebouncedForTeam はチームごとにisDebouncedと同じチェックを行う
+// 履歴を保存できない場合（ファイルに書き込めないなど）はプロセス内の履歴で判定する
+func isDebouncedForTeam(team, text string) bool {
 	// 空テキストは常にデバウンスする（処理させない）
 	if text == "" {
 		return true
 	}
 
+	debounceMutex.Lock()
+	config := debounceConfigFor(team)
+	store, fallback := debounceStore, fallbackDebounceStore
+	debounceMutex.Unlock()
+
+	var debounced bool
+	check := func(entries map[string]debounceEntry) error {
+		debounced = checkDebounce(entries, text, config)
+		return nil
+	}
+	if err := store.Update(team, check); err != nil {
+		_ = fallback.Update(team, check)
+	}
+	return debounced
+}
+
+// checkDebounce は履歴entriesと比べてtextをデバウンスするかどうかを判定する
+// デバウンスしない場合はtextを履歴に追加し、古いエントリを削除する
+func checkDebounce(entries map[string]debounceEntry, text string, config DebounceConfig) bool {
 	// 完全一致チェック
-	if entry, exists := debounceMap[text]; exists {
-		if time.Since(entry.timestamp) < debounceConfig.Duration {
+	if entry, exists := entries[text]; exists {
+		if time.Since(entry.Timestamp) < config.Duration {
 			// 設定時間以内の同一テキスト入力
 			return true
 		}
 	}
 
 	// 類似度チェック
-	for storedText, entry := range debounceMap {
+	match := similarityMetricFor(config).Matcher(text, config.SimilarityThreshold)
+	for storedText, entry := range entries {
 		// 有効期限内のエントリのみチェック
-		if time.Since(entry.timestamp) < debounceConfig.Duration {
+		if time.Since(entry.Timestamp) < config.Duration {
 			// 両方のテキストが意味のある長さを持つ場合のみ類似度を計算
 			if len(text) > 1 && len(storedText) > 1 {
-				similarity := textSimilarity(text, storedText)
-
-				if similarity >= debounceConfig.SimilarityThreshold {
+				if match(storedText) {
 					return true
 				}
 			}
@@ -171,15 +543,15 @@ func isDebounced(text string) bool {
 	}
 
 	// エントリを追加
-	debounceMap[text] = debounceEntry{
-		text:      text,
-		timestamp: time.Now(),
+	entries[text] = debounceEntry{
+		Text:      text,
+		Timestamp: time.Now(),
 	}
 
 	// マップのクリーンアップ（古いエントリを削除）
-	for key, entry := range debounceMap {
-		if time.Since(entry.timestamp) > debounceConfig.Duration*2 {
-			delete(debounceMap, key)
+	for key, entry := range entries {
+		if time.Since(entry.Timestamp) > config.Duration*2 {
+			delete(entries, key)
 		}
 	}
 
@@ -194,10 +566,388 @@ func stripPrefix(s string, prefix string) string {
 	return s
 }
 
+// splitList はカンマ区切りの文字列を空白を除いた要素のスライスに分割する
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // GenerateTimestampWithAnchor は時刻をアンカーリンク付きで生成する
+// 時刻はtのタイムゾーンで表示するため、呼び出し元で日報のタイムゾーンにしておく
 // 例: <a id="1234" href="#1234">12:34</a>
 func GenerateTimestampWithAnchor(t time.Time) string {
 	timeStr := fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
 	anchorID := fmt.Sprintf("%02d%02d", t.Hour(), t.Minute())
 	return fmt.Sprintf("<a id=\"%s\" href=\"#%s\">%s</a>", anchorID, anchorID, timeStr)
 }
+
+// GenerateUniqueTimestampWithAnchor は既存の本文bodyMdと重複しないアンカーIDで、時刻をアンカーリンク付きで生成する
+// 同じ分のアンカーが既にある場合は連番を付ける（表示する時刻はどちらも12:34のまま）
+// 例: <a id="1234-2" href="#1234-2">12:34</a>
+func GenerateUniqueTimestampWithAnchor(t time.Time, bodyMd string) string {
+	timeStr := fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
+	base := fmt.Sprintf("%02d%02d", t.Hour(), t.Minute())
+
+	used := make(map[string]bool)
+	for _, m := range anchorIDAttrPattern.FindAllStringSubmatch(bodyMd, -1) {
+		used[m[1]] = true
+	}
+
+	anchorID := base
+	for seq := 2; used[anchorID]; seq++ {
+		anchorID = fmt.Sprintf("%s-%d", base, seq)
+	}
+	return fmt.Sprintf("<a id=\"%s\" href=\"#%s\">%s</a>", anchorID, anchorID, timeStr)
+}
+
+// anchorIDAttrPattern は本文中のid属性にマッチする（手で書かれたアンカーとの重複も避けるため）
+var anchorIDAttrPattern = regexp.MustCompile(`\bid="([^"]*)"`)
+
+// anchorIDPattern は時刻のアンカーID（1234、または連番付きの1234-2）にマッチする
+// 時刻の表記（12:34）も受け付ける
+var anchorIDPattern = regexp.MustCompile(`^(\d{2}):?(\d{2})(?:-(\d+))?$`)
+
+// ParseAnchorID は時刻のアンカーIDを時刻（HHMM）と連番に分解する
+// 連番のない従来のアンカーID（1234）は連番1として扱う
+func ParseAnchorID(anchorID string) (hhmm string, seq int, ok bool) {
+	m := anchorIDPattern.FindStringSubmatch(anchorID)
+	if m == nil {
+		return "", 0, false
+	}
+	seq = 1
+	if m[3] != "" {
+		n, err := strconv.Atoi(m[3])
+		if err != nil || n < 1 {
+			return "", 0, false
+		}
+		seq = n
+	}
+	return m[1] + m[2], seq, true
+}
+
+// sameAnchorID は2つのアンカーIDが同じエントリを指すかどうかを返す
+// 1234と1234-1、12:34と1234のように表記が異なっても同じ時刻・連番なら一致とみなす
+func sameAnchorID(a, b string) bool {
+	if a == b {
+		return true
+	}
+	hhmmA, seqA, okA := ParseAnchorID(a)
+	hhmmB, seqB, okB := ParseAnchorID(b)
+	return okA && okB && hhmmA == hhmmB && seqA == seqB
+}
+
+// entryAnchorPattern はGenerateTimestampWithAnchorが生成する行頭のアンカーにマッチする
+var entryAnchorPattern = regexp.MustCompile(`(?m)^<a id="([^"]*)" href="#[^"]*">([^<]*)</a>[ \t]?`)
+
+// ParseDailyReportEntries は日報のBodyMdをエントリ単位に分解する
+// アンカー付きの時刻で始まる行をエントリの先頭とみなし、末尾の区切り線（---）は取り除く
+// 最初のアンカーより前に書かれたテキストは時刻なしのエントリとして扱う
+func ParseDailyReportEntries(bodyMd string) []DailyReportEntry {
+	entries := []DailyReportEntry{}
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+
+	matches := entryAnchorPattern.FindAllStringSubmatchIndex(body, -1)
+
+	// 先頭のアンカーより前のテキスト
+	head := body
+	if len(matches) > 0 {
+		head = body[:matches[0][0]]
+	}
+	if text := trimEntryText(head); text != "" {
+		entries = append(entries, DailyReportEntry{Text: text})
+	}
+
+	for i, m := range matches {
+		end := entryEnd(body, matches, i)
+		entries = append(entries, DailyReportEntry{
+			Time:     body[m[4]:m[5]],
+			AnchorID: body[m[2]:m[3]],
+			Text:     trimEntryText(body[m[1]:end]),
+		})
+	}
+
+	return entries
+}
+
+// errEntryNotFound は指定したエントリが日報に存在しないことを表す
+var errEntryNotFound = newToolError(ErrNotFound, "指定したエントリが見つかりません")
+
+// entrySpan はBodyMd内のアンカー付きエントリ1件分の位置を表す
+type entrySpan struct {
+	start     int // アンカーの先頭
+	textStart int // 本文の先頭
+	textEnd   int // 本文の末尾（区切り線の直前）
+	end       int // 次のエントリ（またはセクションの見出し）の先頭
+	anchorID  string
+	time      string
+}
+
+// sectionAfterSeparatorPattern は区切り線（---）の直後に続く見出しにマッチする
+var sectionAfterSeparatorPattern = regexp.MustCompile(`(?m)^---[ \t]*\n(?:[ \t]*\n)*(#{1,6}[ \t])`)
+
+// entryEnd はi番目のアンカーで始まるエントリの終わりの位置を返す
+// 次のエントリの先頭までだが、区切り線の後に見出しが続く場合はセクションの終わりとして見出しの直前までとする
+func entryEnd(body string, matches [][]int, i int) int {
+	end := len(body)
+	if i+1 < len(matches) {
+		end = matches[i+1][0]
+	}
+	if loc := sectionAfterSeparatorPattern.FindStringSubmatchIndex(body[matches[i][1]:end]); loc != nil {
+		end = matches[i][1] + loc[2]
+	}
+	return end
+}
+
+// findEntrySpans はBodyMd内のアンカー付きエントリの位置を先頭から順に返す
+func findEntrySpans(body string) []entrySpan {
+	matches := entryAnchorPattern.FindAllStringSubmatchIndex(body, -1)
+	spans := make([]entrySpan, 0, len(matches))
+	for i, m := range matches {
+		end := entryEnd(body, matches, i)
+		segment := body[m[1]:end]
+		text := trimEntryText(segment)
+		textStart := m[1] + len(segment) - len(strings.TrimLeftFunc(segment, unicode.IsSpace))
+		spans = append(spans, entrySpan{
+			start:     m[0],
+			textStart: textStart,
+			textEnd:   textStart + len(text),
+			end:       end,
+			anchorID:  body[m[2]:m[3]],
+			time:      body[m[4]:m[5]],
+		})
+	}
+	return spans
+}
+
+// findEntry はアンカーIDまたは1始まりの番号（アンカー付きエントリの上からの順番）でエントリを探す
+// アンカーIDが重複している場合は番号での指定を求める
+func findEntry(body, anchorID string, index int) (entrySpan, error) {
+	spans := findEntrySpans(body)
+	if anchorID == "" {
+		if index < 1 || index > len(spans) {
+			return entrySpan{}, fmt.Errorf("%w: index=%d（エントリは%d件です）", errEntryNotFound, index, len(spans))
+		}
+		return spans[index-1], nil
+	}
+
+	var found []entrySpan
+	for i, span := range spans {
+		if sameAnchorID(span.anchorID, anchorID) && (index == 0 || index == i+1) {
+			found = append(found, span)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return entrySpan{}, fmt.Errorf("%w: anchor_id=%s", errEntryNotFound, anchorID)
+	case 1:
+		return found[0], nil
+	default:
+		return entrySpan{}, newToolError(ErrInvalidParams, "アンカー%sのエントリが%d件あります。indexで指定してください", anchorID, len(found))
+	}
+}
+
+// FindDailyReportEntry はアンカーIDまたは番号で指定したエントリを返す
+func FindDailyReportEntry(bodyMd, anchorID string, index int) (DailyReportEntry, error) {
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+	span, err := findEntry(body, anchorID, index)
+	if err != nil {
+		return DailyReportEntry{}, err
+	}
+	return DailyReportEntry{
+		Time:     span.time,
+		AnchorID: span.anchorID,
+		Text:     body[span.textStart:span.textEnd],
+	}, nil
+}
+
+// ReplaceDailyReportEntry は指定したエントリの本文をtextに置き換える
+// アンカーと区切り線、他のエントリの順番はそのまま残す
+func ReplaceDailyReportEntry(bodyMd, anchorID string, index int, text string) (string, error) {
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+	span, err := findEntry(body, anchorID, index)
+	if err != nil {
+		return "", err
+	}
+	return body[:span.textStart] + text + body[span.textEnd:], nil
+}
+
+// RemoveDailyReportEntry は指定したエントリを区切り線ごと取り除く
+func RemoveDailyReportEntry(bodyMd, anchorID string, index int) (string, error) {
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+	span, err := findEntry(body, anchorID, index)
+	if err != nil {
+		return "", err
+	}
+	if span.end == len(body) {
+		// 末尾のエントリを消した場合は、直前のエントリの区切り線で終わるようにする
+		return strings.TrimRightFunc(body[:span.start], unicode.IsSpace), nil
+	}
+	return body[:span.start] + body[span.end:], nil
+}
+
+// PlaceDailyReportEntry は日報の本文bodyMdに時刻atのエントリentry（アンカー付きの時刻で始まるテキスト）をplacementに従って書く
+// どの位置に書く場合も、エントリの後ろに区切り線（---）を置き、エントリの間は空行で区切る
+func PlaceDailyReportEntry(bodyMd, entry, placement string, at time.Time, rolloverHour int) string {
+	switch placement {
+	case entryPlacementAppend:
+		return appendDailyReportEntry(bodyMd, entry)
+	case entryPlacementInsertByTime:
+		return InsertDailyReportEntry(bodyMd, entry, at, rolloverHour, false)
+	default:
+		return prependDailyReportEntry(bodyMd, entry)
+	}
+}
+
+// PlaceDailyReportEntryInSection は日報の本文bodyMdの見出しsectionの下に、エントリentryをplacementに従って書く
+// sectionが空の場合や、見出しが手で消されて見つからない場合は本文全体に対してplacementに従って書き、falseを返す
+func PlaceDailyReportEntryInSection(bodyMd, entry, section, placement string, at time.Time, rolloverHour int) (string, bool) {
+	return editDailyReportSection(bodyMd, section, func(content string) string {
+		return PlaceDailyReportEntry(content, entry, placement, at, rolloverHour)
+	})
+}
+
+// sectionHeadingPattern はMarkdownの見出しの行（# 見出し）にマッチする
+var sectionHeadingPattern = regexp.MustCompile(`(?m)^(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+
+// findDailyReportSection は本文の中の見出しがsectionのセクションの範囲を返す
+// 範囲は見出しの行の直後から、同じかより上のレベルの次の見出しの直前（なければ本文の末尾）まで
+// 見出しは前後の空白、先頭の#、大文字小文字の違いを無視して比べる
+func findDailyReportSection(body, section string) (start, end int, ok bool) {
+	section = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(section), "#"))
+	if section == "" {
+		return 0, 0, false
+	}
+
+	matches := sectionHeadingPattern.FindAllStringSubmatchIndex(body, -1)
+	for i, m := range matches {
+		if !strings.EqualFold(body[m[4]:m[5]], section) {
+			continue
+		}
+		level := m[3] - m[2]
+		end = len(body)
+		for _, next := range matches[i+1:] {
+			if next[3]-next[2] <= level {
+				end = next[0]
+				break
+			}
+		}
+		return m[1], end, true
+	}
+	return 0, 0, false
+}
+
+// HasDailyReportSection は本文に見出しがsectionのセクションがあるかどうかを返す
+func HasDailyReportSection(bodyMd, section string) bool {
+	_, _, ok := findDailyReportSection(strings.ReplaceAll(bodyMd, "\r\n", "\n"), section)
+	return ok
+}
+
+// editDailyReportSection は本文の見出しsectionの下の内容をeditで書き換える
+// セクションが見つからない場合は本文全体をeditで書き換えてfalseを返す
+func editDailyReportSection(bodyMd, section string, edit func(content string) string) (string, bool) {
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+	start, end, ok := findDailyReportSection(body, section)
+	if !ok {
+		return edit(body), false
+	}
+
+	// 見出しの後に空行を1つ置き、次の見出しとの間も空行で区切る
+	result := body[:start] + "\n\n" + edit(strings.TrimSpace(body[start:end]))
+	if rest := body[end:]; rest != "" {
+		result += "\n\n" + rest
+	}
+	return result, true
+}
+
+// prependDailyReportEntry は本文の先頭にエントリを書く
+func prependDailyReportEntry(bodyMd, entry string) string {
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+	if strings.TrimSpace(body) == "" {
+		return entry + "\n\n---"
+	}
+	return entry + "\n\n---\n\n" + body
+}
+
+// appendDailyReportEntry は本文の末尾にエントリを書く（末尾が区切り線でなければ区切り線を補う）
+func appendDailyReportEntry(bodyMd, entry string) string {
+	body := strings.TrimRightFunc(strings.ReplaceAll(bodyMd, "\r\n", "\n"), unicode.IsSpace)
+	if body == "" {
+		return entry + "\n\n---"
+	}
+	if !strings.HasSuffix(body, "---") {
+		body += "\n\n---"
+	}
+	return body + "\n\n" + entry + "\n\n---"
+}
+
+// InsertDailyReportEntry は時刻atのエントリentry（アンカー付きの時刻で始まるテキスト）を、エントリの中の時刻順の位置に挿入する
+// oldestFirstならエントリが古い順、そうでなければ新しい順に並んでいるものとし、同じ時刻のエントリより新しいものとして扱う
+// rolloverHourより前の時刻は日付が変わった後の時刻として、0時より前のエントリより新しいものとみなす
+func InsertDailyReportEntry(bodyMd, entry string, at time.Time, rolloverHour int, oldestFirst bool) string {
+	body := strings.ReplaceAll(bodyMd, "\r\n", "\n")
+	atMinutes := minutesInReportDay(at.Hour(), at.Minute(), rolloverHour)
+	for _, span := range findEntrySpans(body) {
+		entryTime, err := time.Parse("15:04", span.time)
+		if err != nil {
+			// 時刻を読めないエントリは比較の対象にしない
+			continue
+		}
+		minutes := minutesInReportDay(entryTime.Hour(), entryTime.Minute(), rolloverHour)
+		if (oldestFirst && minutes > atMinutes) || (!oldestFirst && minutes <= atMinutes) {
+			return body[:span.start] + entry + "\n\n---\n\n" + body[span.start:]
+		}
+	}
+	// 新しい順ならすべてのエントリより古い場合、古い順ならすべてのエントリより新しい場合は末尾に追加する
+	return appendDailyReportEntry(body, entry)
+}
+
+// minutesInReportDay は日報の日の開始（rolloverHour時）からの経過分を返す
+func minutesInReportDay(hour, minute, rolloverHour int) int {
+	if hour < rolloverHour {
+		hour += 24
+	}
+	return (hour-rolloverHour)*60 + minute
+}
+
+// mergeDailyReportBodies は日報の本文primaryの末尾に、重複した日報の本文othersを区切り線でつないで追加する
+func mergeDailyReportBodies(primary string, others []string) string {
+	merged := strings.TrimRight(primary, " \t\n")
+	for _, other := range others {
+		other = strings.TrimSpace(other)
+		if other == "" {
+			continue
+		}
+		if merged != "" {
+			if !strings.HasSuffix(merged, "---") {
+				merged += "\n\n---"
+			}
+			merged += "\n\n"
+		}
+		merged += other
+	}
+	return merged
+}
+
+// trimEntryText はエントリ末尾の区切り線と前後の空白を除去する
+func trimEntryText(s string) string {
+	s = strings.TrimSpace(s)
+	for strings.HasSuffix(s, "\n---") || s == "---" {
+		s = strings.TrimSpace(strings.TrimSuffix(s, "---"))
+	}
+	return s
+}
+
+// truncateRunes は文字列を指定したrune数までに切り詰める
+// 切り詰めた場合は末尾に「…」を付与する
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
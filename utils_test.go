@@ -1,6 +1,11 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -95,6 +100,39 @@ func TestIsDebounced(t *testing.T) {
 	}
 }
 
+func TestIsDebouncedForTeam(t *testing.T) {
+	resetDebounce()
+	SetTeamDebounceConfig("strict", DebounceConfig{Duration: time.Minute, SimilarityThreshold: 0.5})
+	defer func() {
+		debounceMutex.Lock()
+		delete(teamDebounceConfigs, "strict")
+		debounceMutex.Unlock()
+	}()
+
+	text := "team message"
+	if isDebouncedForTeam("work", text) {
+		t.Error("初回呼び出しでdebounceされるべきではない")
+	}
+	if !isDebouncedForTeam("work", text) {
+		t.Error("同じチームの2回目の呼び出しはdebounceされるべき")
+	}
+	if isDebouncedForTeam("personal", text) {
+		t.Error("別のチームの同じテキストはdebounceされるべきでない")
+	}
+
+	// チームごとの設定が使われる
+	if got := DebounceDuration("strict"); got != time.Minute {
+		t.Errorf("DebounceDuration(strict) = %v, want %v", got, time.Minute)
+	}
+	if got := DebounceDuration("work"); got != debounceConfig.Duration {
+		t.Errorf("DebounceDuration(work) = %v, want %v", got, debounceConfig.Duration)
+	}
+	isDebouncedForTeam("strict", "abcdefghij")
+	if !isDebouncedForTeam("strict", "abcdefXXXX") {
+		t.Error("しきい値0.5のチームでは60%類似のテキストはdebounceされるべき")
+	}
+}
+
 // テキスト類似度計算のテスト
 func TestTextSimilarity(t *testing.T) {
 	testCases := []struct {
@@ -296,6 +334,141 @@ func TestLevenshteinDistance(t *testing.T) {
 	}
 }
 
+// naiveLevenshteinDistance は比較用の素朴なレーベンシュタイン距離の実装
+func naiveLevenshteinDistance(s1, s2 string) int {
+	r1, r2 := []rune(s1), []rune(s2)
+	matrix := make([][]int, len(r1)+1)
+	for i := range matrix {
+		matrix[i] = make([]int, len(r2)+1)
+		matrix[i][0] = i
+	}
+	for j := range matrix[0] {
+		matrix[0][j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			matrix[i][j] = min(matrix[i-1][j]+1, matrix[i][j-1]+1, matrix[i-1][j-1]+cost)
+		}
+	}
+	return matrix[len(r1)][len(r2)]
+}
+
+// similarityAtLeastとboundedLevenshteinDistanceが素朴な実装と同じ結果になることのテスト
+func TestSimilarityAtLeast(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	alphabet := []rune("abcあいう日報 ")
+	randomText := func() string {
+		runes := make([]rune, rng.IntN(12))
+		for i := range runes {
+			runes[i] = alphabet[rng.IntN(len(alphabet))]
+		}
+		return string(runes)
+	}
+
+	for range 2000 {
+		s1, s2 := randomText(), randomText()
+		expected := naiveLevenshteinDistance(s1, s2)
+		if d := levenshteinDistance(s1, s2); d != expected {
+			t.Fatalf("levenshteinDistance(%q, %q) = %d, 期待値: %d", s1, s2, d, expected)
+		}
+
+		limit := rng.IntN(6)
+		bounded := boundedLevenshteinDistance([]rune(s1), []rune(s2), limit)
+		if expected <= limit && bounded != expected || expected > limit && bounded != limit+1 {
+			t.Fatalf("boundedLevenshteinDistance(%q, %q, %d) = %d, 距離: %d", s1, s2, limit, bounded, expected)
+		}
+
+		for _, threshold := range []float64{0, 0.5, 0.7, 0.9, 1} {
+			want := textSimilarity(s1, s2) >= threshold
+			if got := similarityAtLeast(s1, s2, threshold); got != want {
+				t.Fatalf("similarityAtLeast(%q, %q, %g) = %t, 期待値: %t", s1, s2, threshold, got, want)
+			}
+		}
+	}
+}
+
+// benchmarkText はベンチマーク用の長いテキストを生成する
+func benchmarkText(rng *rand.Rand, length int) string {
+	alphabet := []rune("あいうえおかきくけこさしすせそ日報作業確認実装テストabcdefghijklmnopqrstuvwxyz 、。\n")
+	runes := make([]rune, length)
+	for i := range runes {
+		runes[i] = alphabet[rng.IntN(len(alphabet))]
+	}
+	return string(runes)
+}
+
+// 1万文字のテキスト同士の類似度判定のベンチマーク
+func BenchmarkSimilarityAtLeast(b *testing.B) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	base := benchmarkText(rng, 10000)
+
+	// 数か所だけ修正したテキスト（実際の重複投稿）
+	edited := []rune(base)
+	for i := 0; i < 20; i++ {
+		edited[rng.IntN(len(edited))] = '＊'
+	}
+
+	benchmarks := []struct {
+		name  string
+		other string
+	}{
+		{name: "同一", other: base},
+		{name: "数か所の修正", other: string(edited)},
+		{name: "末尾に追記", other: base + "追記した内容"},
+		{name: "無関係なテキスト", other: benchmarkText(rng, 10000)},
+		{name: "長さが大きく異なる", other: base[:len(base)/2]},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				similarityAtLeast(base, bm.other, 0.9)
+			}
+		})
+	}
+}
+
+// isDebouncedTarget はデバウンス判定1回あたりの目標時間
+const isDebouncedTarget = time.Millisecond
+
+// 1万文字のテキストを履歴と比較するデバウンス判定のベンチマーク
+// 1回あたりの時間が目標を超えた場合は失敗する
+func BenchmarkIsDebounced(b *testing.B) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	history := make([]string, 20)
+	for i := range history {
+		history[i] = benchmarkText(rng, 10000)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// 投稿するテキストは毎回異なる
+		b.StopTimer()
+		resetDebounce()
+		for _, h := range history {
+			isDebounced(h)
+		}
+		text := benchmarkText(rng, 10000)
+		b.StartTimer()
+		isDebounced(text)
+	}
+	b.StopTimer()
+
+	// 最初の数回はキャッシュやGCの影響が大きいため、回数が少ない実行では判定しない
+	if b.N < 100 {
+		return
+	}
+	if perOp := b.Elapsed() / time.Duration(b.N); perOp > isDebouncedTarget {
+		b.Errorf("デバウンス判定に%vかかりました（目標: %v以内）", perOp, isDebouncedTarget)
+	}
+}
+
 // GenerateTimestampWithAnchor関数のテスト
 func TestGenerateTimestampWithAnchor(t *testing.T) {
 	testCases := []struct {
@@ -334,3 +507,548 @@ func TestGenerateTimestampWithAnchor(t *testing.T) {
 		})
 	}
 }
+
+// GenerateUniqueTimestampWithAnchor関数のテスト
+func TestGenerateUniqueTimestampWithAnchor(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 34, 56, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		bodyMd   string
+		expected string
+	}{
+		{
+			name:     "空の本文",
+			bodyMd:   "",
+			expected: `<a id="1234" href="#1234">12:34</a>`,
+		},
+		{
+			name:     "別の分のアンカーのみ",
+			bodyMd:   "<a id=\"1233\" href=\"#1233\">12:33</a> 前の投稿\n\n---",
+			expected: `<a id="1234" href="#1234">12:34</a>`,
+		},
+		{
+			name:     "同じ分のアンカーがある",
+			bodyMd:   "<a id=\"1234\" href=\"#1234\">12:34</a> 前の投稿\n\n---",
+			expected: `<a id="1234-2" href="#1234-2">12:34</a>`,
+		},
+		{
+			name:     "連番付きのアンカーもある",
+			bodyMd:   "<a id=\"1234-2\" href=\"#1234-2\">12:34</a> 2件目\n\n---\n\n<a id=\"1234\" href=\"#1234\">12:34</a> 1件目\n\n---",
+			expected: `<a id="1234-3" href="#1234-3">12:34</a>`,
+		},
+		{
+			name:     "手で書かれたアンカー",
+			bodyMd:   "<h2 id=\"1234\">見出し</h2>",
+			expected: `<a id="1234-2" href="#1234-2">12:34</a>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := GenerateUniqueTimestampWithAnchor(at, tc.bodyMd)
+			if result != tc.expected {
+				t.Errorf("期待値: %q, 実際: %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// ParseAnchorID関数のテスト
+func TestParseAnchorID(t *testing.T) {
+	testCases := []struct {
+		anchorID string
+		hhmm     string
+		seq      int
+		ok       bool
+	}{
+		{anchorID: "1234", hhmm: "1234", seq: 1, ok: true},
+		{anchorID: "1234-2", hhmm: "1234", seq: 2, ok: true},
+		{anchorID: "12:34", hhmm: "1234", seq: 1, ok: true},
+		{anchorID: "0930-10", hhmm: "0930", seq: 10, ok: true},
+		{anchorID: "1234-0", ok: false},
+		{anchorID: "123", ok: false},
+		{anchorID: "memo", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.anchorID, func(t *testing.T) {
+			hhmm, seq, ok := ParseAnchorID(tc.anchorID)
+			if hhmm != tc.hhmm || seq != tc.seq || ok != tc.ok {
+				t.Errorf("期待値: (%q, %d, %t), 実際: (%q, %d, %t)", tc.hhmm, tc.seq, tc.ok, hhmm, seq, ok)
+			}
+		})
+	}
+
+	t.Run("従来のアンカーIDと連番付きのアンカーIDを区別して指定できる", func(t *testing.T) {
+		body := "<a id=\"1234-2\" href=\"#1234-2\">12:34</a> 2件目\n\n---\n\n<a id=\"1234\" href=\"#1234\">12:34</a> 1件目\n\n---"
+		for anchorID, expected := range map[string]string{"1234": "1件目", "1234-1": "1件目", "12:34": "1件目", "1234-2": "2件目"} {
+			entry, err := FindDailyReportEntry(body, anchorID, 0)
+			if err != nil {
+				t.Fatalf("%s: 予期しないエラー: %v", anchorID, err)
+			}
+			if entry.Text != expected {
+				t.Errorf("%s: 期待値: %q, 実際: %q", anchorID, expected, entry.Text)
+			}
+		}
+	})
+}
+
+// ParseDailyReportEntries関数のテスト
+func TestParseDailyReportEntries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bodyMd   string
+		expected []DailyReportEntry
+	}{
+		{
+			name:     "空の本文",
+			bodyMd:   "",
+			expected: []DailyReportEntry{},
+		},
+		{
+			name:   "1件のエントリ",
+			bodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> テスト投稿内容\n\n---",
+			expected: []DailyReportEntry{
+				{Time: "13:00", AnchorID: "1300", Text: "テスト投稿内容"},
+			},
+		},
+		{
+			name:   "複数のエントリ",
+			bodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n<a id=\"0930\" href=\"#0930\">09:30</a> 朝の作業\n\n---",
+			expected: []DailyReportEntry{
+				{Time: "13:00", AnchorID: "1300", Text: "午後の作業"},
+				{Time: "09:30", AnchorID: "0930", Text: "朝の作業"},
+			},
+		},
+		{
+			name:   "複数行のエントリ",
+			bodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 1行目\n- 箇条書き\n- 箇条書き2\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 既存の内容\n\n---",
+			expected: []DailyReportEntry{
+				{Time: "13:00", AnchorID: "1300", Text: "1行目\n- 箇条書き\n- 箇条書き2"},
+				{Time: "10:00", AnchorID: "1000", Text: "既存の内容"},
+			},
+		},
+		{
+			name:   "アンカーより前のテキスト",
+			bodyMd: "# 今日の予定\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 既存の内容\n\n---",
+			expected: []DailyReportEntry{
+				{Text: "# 今日の予定"},
+				{Time: "10:00", AnchorID: "1000", Text: "既存の内容"},
+			},
+		},
+		{
+			name:   "CRLFの改行",
+			bodyMd: "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\r\n\r\n---\r\n\r\n<a id=\"0930\" href=\"#0930\">09:30</a> 朝の作業\r\n\r\n---",
+			expected: []DailyReportEntry{
+				{Time: "13:00", AnchorID: "1300", Text: "午後の作業"},
+				{Time: "09:30", AnchorID: "0930", Text: "朝の作業"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ParseDailyReportEntries(tc.bodyMd)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("期待値: %#v, 実際: %#v", tc.expected, result)
+			}
+		})
+	}
+}
+
+// ReplaceDailyReportEntry・RemoveDailyReportEntry関数のテスト
+func TestReplaceAndRemoveDailyReportEntry(t *testing.T) {
+	body := "# 今日の予定\n\n---\n\n" +
+		"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+		"<a id=\"1000\" href=\"#1000\">10:00</a> 1行目\n- 箇条書き\n\n---\n\n" +
+		"<a id=\"0930\" href=\"#0930\">09:30</a> 朝の作業\n\n---"
+
+	replaceCases := []struct {
+		name     string
+		anchorID string
+		index    int
+		text     string
+		expected string
+	}{
+		{
+			name:     "アンカーIDで指定",
+			anchorID: "1000",
+			text:     "修正した内容",
+			expected: "# 今日の予定\n\n---\n\n" +
+				"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 修正した内容\n\n---\n\n" +
+				"<a id=\"0930\" href=\"#0930\">09:30</a> 朝の作業\n\n---",
+		},
+		{
+			name:  "番号で指定（末尾のエントリ）",
+			index: 3,
+			text:  "複数行の\n修正",
+			expected: "# 今日の予定\n\n---\n\n" +
+				"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 1行目\n- 箇条書き\n\n---\n\n" +
+				"<a id=\"0930\" href=\"#0930\">09:30</a> 複数行の\n修正\n\n---",
+		},
+	}
+	for _, tc := range replaceCases {
+		t.Run("置き換え/"+tc.name, func(t *testing.T) {
+			result, err := ReplaceDailyReportEntry(body, tc.anchorID, tc.index, tc.text)
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("期待値: %q, 実際: %q", tc.expected, result)
+			}
+		})
+	}
+
+	removeCases := []struct {
+		name     string
+		anchorID string
+		index    int
+		expected string
+	}{
+		{
+			name:     "先頭のエントリ",
+			anchorID: "1300",
+			expected: "# 今日の予定\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 1行目\n- 箇条書き\n\n---\n\n" +
+				"<a id=\"0930\" href=\"#0930\">09:30</a> 朝の作業\n\n---",
+		},
+		{
+			name:  "末尾のエントリ",
+			index: 3,
+			expected: "# 今日の予定\n\n---\n\n" +
+				"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 1行目\n- 箇条書き\n\n---",
+		},
+	}
+	for _, tc := range removeCases {
+		t.Run("削除/"+tc.name, func(t *testing.T) {
+			result, err := RemoveDailyReportEntry(body, tc.anchorID, tc.index)
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("期待値: %q, 実際: %q", tc.expected, result)
+			}
+		})
+	}
+
+	t.Run("存在しないエントリ", func(t *testing.T) {
+		if _, err := ReplaceDailyReportEntry(body, "2359", 0, "テスト"); !errors.Is(err, errEntryNotFound) {
+			t.Errorf("errEntryNotFoundが返るべき: %v", err)
+		}
+		if _, err := RemoveDailyReportEntry(body, "", 4); !errors.Is(err, errEntryNotFound) {
+			t.Errorf("errEntryNotFoundが返るべき: %v", err)
+		}
+	})
+
+	t.Run("アンカーIDが重複している場合は番号が必要", func(t *testing.T) {
+		duplicated := "<a id=\"1234\" href=\"#1234\">12:34</a> 2件目\n\n---\n\n<a id=\"1234\" href=\"#1234\">12:34</a> 1件目\n\n---"
+		if _, err := RemoveDailyReportEntry(duplicated, "1234", 0); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("ErrInvalidParamsが返るべき: %v", err)
+		}
+		result, err := RemoveDailyReportEntry(duplicated, "1234", 2)
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if expected := "<a id=\"1234\" href=\"#1234\">12:34</a> 2件目\n\n---"; result != expected {
+			t.Errorf("期待値: %q, 実際: %q", expected, result)
+		}
+	})
+}
+
+func TestInsertDailyReportEntry(t *testing.T) {
+	body := "# 今日の予定\n\n---\n\n" +
+		"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+		"<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---"
+	entry := func(hhmm string) string {
+		return fmt.Sprintf("<a id=\"%s\" href=\"#%s\">%s:%s</a> 書き忘れた作業", hhmm, hhmm, hhmm[:2], hhmm[2:])
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 5, 3, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		at           time.Time
+		rolloverHour int
+		expected     string
+	}{
+		{
+			name: "エントリの間",
+			body: body,
+			at:   at(11, 30),
+			expected: "# 今日の予定\n\n---\n\n" +
+				"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+				entry("1130") + "\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---",
+		},
+		{
+			name: "最も新しい（時刻のないテキストの下）",
+			body: body,
+			at:   at(14, 0),
+			expected: "# 今日の予定\n\n---\n\n" +
+				entry("1400") + "\n\n---\n\n" +
+				"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---",
+		},
+		{
+			name:     "最も古い",
+			body:     body,
+			at:       at(9, 0),
+			expected: body + "\n\n" + entry("0900") + "\n\n---",
+		},
+		{
+			name: "同じ時刻のエントリの上",
+			body: body,
+			at:   at(10, 0),
+			expected: "# 今日の予定\n\n---\n\n" +
+				"<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n" +
+				entry("1000") + "\n\n---\n\n" +
+				"<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---",
+		},
+		{
+			name:         "日付が変わった後の時刻は0時前のエントリより新しい",
+			body:         "<a id=\"2300\" href=\"#2300\">23:00</a> 夜の作業\n\n---\n\n" + "<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---",
+			at:           at(0, 30),
+			rolloverHour: 4,
+			expected:     entry("0030") + "\n\n---\n\n" + "<a id=\"2300\" href=\"#2300\">23:00</a> 夜の作業\n\n---\n\n" + "<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---",
+		},
+		{
+			name:     "空の本文",
+			body:     "",
+			at:       at(9, 0),
+			expected: entry("0900") + "\n\n---",
+		},
+		{
+			name:     "区切り線で終わらない本文",
+			body:     "<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n",
+			at:       at(9, 0),
+			expected: "<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---\n\n" + entry("0900") + "\n\n---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hhmm := fmt.Sprintf("%02d%02d", tt.at.Hour(), tt.at.Minute())
+			result := InsertDailyReportEntry(tt.body, entry(hhmm), tt.at, tt.rolloverHour, false)
+			if result != tt.expected {
+				t.Errorf("期待値: %q, 実際: %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// mergeDailyReportBodies関数のテスト
+func TestPlaceDailyReportEntry(t *testing.T) {
+	entry := func(hhmm, text string) string {
+		return fmt.Sprintf("<a id=\"%s\" href=\"#%s\">%s:%s</a> %s", hhmm, hhmm, hhmm[:2], hhmm[2:], text)
+	}
+	// 10:00・13:00の順に書いた日報に、11:30のエントリを書く
+	at := time.Date(2025, 5, 3, 11, 30, 0, 0, time.Local)
+	newestFirst := entry("1300", "午後の作業") + "\n\n---\n\n" + entry("1000", "午前の作業") + "\n\n---"
+	oldestFirst := entry("1000", "午前の作業") + "\n\n---\n\n" + entry("1300", "午後の作業") + "\n\n---"
+
+	tests := []struct {
+		name      string
+		body      string
+		placement string
+		expected  []string
+	}{
+		{
+			name:      "先頭",
+			body:      newestFirst,
+			placement: entryPlacementPrepend,
+			expected:  []string{"11:30", "13:00", "10:00"},
+		},
+		{
+			name:      "デフォルトは先頭",
+			body:      newestFirst,
+			placement: "",
+			expected:  []string{"11:30", "13:00", "10:00"},
+		},
+		{
+			name:      "末尾",
+			body:      oldestFirst,
+			placement: entryPlacementAppend,
+			expected:  []string{"10:00", "13:00", "11:30"},
+		},
+		{
+			name:      "末尾（区切り線の後に空白がある本文）",
+			body:      oldestFirst + "\n\n",
+			placement: entryPlacementAppend,
+			expected:  []string{"10:00", "13:00", "11:30"},
+		},
+		{
+			name:      "時刻順",
+			body:      newestFirst,
+			placement: entryPlacementInsertByTime,
+			expected:  []string{"13:00", "11:30", "10:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PlaceDailyReportEntry(tt.body, entry("1130", "新しい作業"), tt.placement, at, 0)
+
+			var times []string
+			for _, e := range ParseDailyReportEntries(result) {
+				times = append(times, e.Time)
+			}
+			if !reflect.DeepEqual(times, tt.expected) {
+				t.Errorf("エントリの順番 期待値: %v, 実際: %v", tt.expected, times)
+			}
+			// どの位置に書いても、各エントリの後ろに区切り線があり、エントリの間は空行で区切られる
+			if strings.Count(result, "\n\n---") != 3 || !strings.HasSuffix(result, "\n\n---") {
+				t.Errorf("区切り線が3つのエントリの後ろにありません: %q", result)
+			}
+			if strings.Contains(result, "---\n\n\n") || strings.Contains(result, "---<a") {
+				t.Errorf("エントリの間の空行が不正: %q", result)
+			}
+		})
+
+		t.Run(tt.name+"（空の日報）", func(t *testing.T) {
+			result := PlaceDailyReportEntry("", entry("1130", "新しい作業"), tt.placement, at, 0)
+			if expected := entry("1130", "新しい作業") + "\n\n---"; result != expected {
+				t.Errorf("期待値: %q, 実際: %q", expected, result)
+			}
+		})
+	}
+
+	t.Run("古い順に並んだエントリの時刻順の位置に挿入", func(t *testing.T) {
+		result := InsertDailyReportEntry(oldestFirst, entry("1130", "新しい作業"), at, 0, true)
+		expected := entry("1000", "午前の作業") + "\n\n---\n\n" + entry("1130", "新しい作業") + "\n\n---\n\n" + entry("1300", "午後の作業") + "\n\n---"
+		if result != expected {
+			t.Errorf("期待値: %q, 実際: %q", expected, result)
+		}
+
+		// すべてのエントリより新しい場合は末尾
+		result = InsertDailyReportEntry(oldestFirst, entry("1400", "新しい作業"), at.Add(150*time.Minute), 0, true)
+		if expected := oldestFirst + "\n\n" + entry("1400", "新しい作業") + "\n\n---"; result != expected {
+			t.Errorf("期待値: %q, 実際: %q", expected, result)
+		}
+	})
+}
+
+func TestPlaceDailyReportEntryInSection(t *testing.T) {
+	entry := func(hhmm, text string) string {
+		return fmt.Sprintf("<a id=\"%s\" href=\"#%s\">%s:%s</a> %s", hhmm, hhmm, hhmm[:2], hhmm[2:], text)
+	}
+	at := time.Date(2025, 5, 3, 11, 30, 0, 0, time.Local)
+	body := "## やったこと\n\n" + entry("1000", "午前の作業") + "\n\n---\n\n## 明日やること\n\n## 気づき\n"
+
+	tests := []struct {
+		name      string
+		body      string
+		section   string
+		placement string
+		expected  string
+		found     bool
+	}{
+		{
+			name:      "見出しの下の先頭",
+			body:      body,
+			section:   "やったこと",
+			placement: entryPlacementPrepend,
+			expected:  "## やったこと\n\n" + entry("1130", "新しい作業") + "\n\n---\n\n" + entry("1000", "午前の作業") + "\n\n---\n\n## 明日やること\n\n## 気づき\n",
+			found:     true,
+		},
+		{
+			name:      "見出しの下の末尾",
+			body:      body,
+			section:   "やったこと",
+			placement: entryPlacementAppend,
+			expected:  "## やったこと\n\n" + entry("1000", "午前の作業") + "\n\n---\n\n" + entry("1130", "新しい作業") + "\n\n---\n\n## 明日やること\n\n## 気づき\n",
+			found:     true,
+		},
+		{
+			name:      "空のセクション（#付き・大文字小文字を区別しない）",
+			body:      "## TODO\n\n## Notes\n",
+			section:   "## notes",
+			placement: entryPlacementPrepend,
+			expected:  "## TODO\n\n## Notes\n\n" + entry("1130", "新しい作業") + "\n\n---",
+			found:     true,
+		},
+		{
+			name:      "見出しがなければ本文全体に書く",
+			body:      body,
+			section:   "振り返り",
+			placement: entryPlacementPrepend,
+			expected:  entry("1130", "新しい作業") + "\n\n---\n\n" + body,
+			found:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, found := PlaceDailyReportEntryInSection(tt.body, entry("1130", "新しい作業"), tt.section, tt.placement, at, 0)
+			if result != tt.expected {
+				t.Errorf("期待値: %q, 実際: %q", tt.expected, result)
+			}
+			if found != tt.found {
+				t.Errorf("見出しの有無 期待値: %v, 実際: %v", tt.found, found)
+			}
+		})
+	}
+
+	t.Run("セクションの最後のエントリは次の見出しを含まない", func(t *testing.T) {
+		entries := ParseDailyReportEntries(body)
+		last := entries[len(entries)-1]
+		if last.Text != "午前の作業" {
+			t.Errorf("期待値: %q, 実際: %q", "午前の作業", last.Text)
+		}
+
+		result, err := RemoveDailyReportEntry(body, "1000", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "## やったこと\n\n## 明日やること\n\n## 気づき\n"; result != expected {
+			t.Errorf("期待値: %q, 実際: %q", expected, result)
+		}
+	})
+}
+
+func TestMergeDailyReportBodies(t *testing.T) {
+	tests := []struct {
+		name     string
+		primary  string
+		others   []string
+		expected string
+	}{
+		{
+			name:     "区切り線で終わる日報",
+			primary:  "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n",
+			others:   []string{"<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---"},
+			expected: "<a id=\"1300\" href=\"#1300\">13:00</a> 午後の作業\n\n---\n\n<a id=\"1000\" href=\"#1000\">10:00</a> 午前の作業\n\n---",
+		},
+		{
+			name:     "区切り線のない日報",
+			primary:  "1件目",
+			others:   []string{"2件目", "3件目"},
+			expected: "1件目\n\n---\n\n2件目\n\n---\n\n3件目",
+		},
+		{
+			name:     "空の本文は追加しない",
+			primary:  "1件目",
+			others:   []string{"", "  \n"},
+			expected: "1件目",
+		},
+		{
+			name:     "まとめ先が空",
+			primary:  "",
+			others:   []string{"2件目"},
+			expected: "2件目",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := mergeDailyReportBodies(tt.primary, tt.others); result != tt.expected {
+				t.Errorf("期待値: %q, 実際: %q", tt.expected, result)
+			}
+		})
+	}
+}